@@ -2,30 +2,55 @@ package Analyzer
 
 import (
 	"encoding/json"
-	"flag"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"proyecto1/DiskManagement"
+	"proyecto1/Errors"
+	"proyecto1/FileSystem"
 	"proyecto1/Structs"
 	"proyecto1/Utilities"
-	"regexp"
 	"strings"
+	"time"
+	"unicode"
 )
 
-var re = regexp.MustCompile(`-(\w+)=("[^"]+"|\S+)`)
+// Usuario dueño de los archivos creados por import
+// Todavía no existe el comando login, mientras tanto se usa root (UID 1, GID 1 en users.txt), la ayuda de import lo indica
+var sessionUID, sessionGID int32 = 1, 1
+
+// Resultado de un comando, se incluye en la respuesta
+// Output es la salida adicional (ruta del reporte, resultado de fsck), Data los datos del resultado
+// y Params los parámetros que se indicaron, ya separados
+type CommandResult struct {
+	Output string
+	Data   interface{}
+	Params map[string]string
+}
 
+// Los comandos se reciben como lista en commands o como un script completo en script, una línea por comando
+// Con dryrun la solicitud completa se ejecuta sin modificar los discos y al final se devuelven los cambios
 type CommandRequest struct {
 	Commands []string `json:"commands"`
+	Script   string   `json:"script,omitempty"`
+	DryRun   bool     `json:"dryrun,omitempty"`
 }
 
 // Estructura para el JSON de respuesta
+// Error es el tipo general del error y Code un código estable más específico, ambos vacíos si el comando funcionó
+// Params son los parámetros que se indicaron, ya separados, y Data los datos del resultado de algunos comandos
 type CommandResponse struct {
-	Command string `json:"command"`
-	Message string `json:"message"`
+	Command    string            `json:"command"`
+	Success    bool              `json:"success"`
+	Message    string            `json:"message"`
+	Error      string            `json:"error,omitempty"` // Tipo de error si el comando falló
+	Code       string            `json:"code,omitempty"`
+	Params     map[string]string `json:"params,omitempty"`
+	DurationMs float64           `json:"duration_ms"`
+	Data       interface{}       `json:"data,omitempty"`
 }
 
 // AnalyzeHandler maneja la solicitud HTTP y ejecuta los comandos
@@ -37,84 +62,131 @@ func AnalyzeHandler(w http.ResponseWriter, r *http.Request) {
 
 	log.Println("Recibiendo solicitud")
 
-	var request CommandRequest
-	decoder := json.NewDecoder(r.Body)
-	err := decoder.Decode(&request)
+	request, err := decodeCommandRequest(r)
 	if err != nil {
 		http.Error(w, "Error decodificando JSON", http.StatusBadRequest)
 		log.Println("Error decodificando JSON:", err)
 		return
 	}
 
-	var responses []CommandResponse
-	var mensaje string
-
-	for _, command := range request.Commands {
-		//Antes de ejecutar el comando reviamos si esta linea es un comentario
-		//Los comentarios tendrán un # al inicio
-		if strings.HasPrefix(command, "#") {
-			responses = append(responses, CommandResponse{
-				Command: "Comentario",
-				Message: fmt.Sprintf("> Comentario: %s", command),
-			})
-			log.Printf("Comentario: %s\n", command)
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusOK)
-			json.NewEncoder(w).Encode(responses)
-			continue
+	responses := []CommandResponse{}
+	dryRun := runRequest(r.Context(), request, func(response CommandResponse) {
+		responses = append(responses, response)
+	})
+	if dryRun != nil {
+		responses = append(responses, *dryRun)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(responses)
+}
+
+// Funcion para leer el JSON de una solicitud de comandos
+func decodeCommandRequest(r *http.Request) (CommandRequest, error) {
+	var request CommandRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	return request, err
+}
+
+// Funcion para obtener los comandos de la solicitud, primero los de commands y luego las líneas del script
+// Las líneas vacías del script se ignoran
+func (request CommandRequest) lines() []string {
+	lines := append([]string{}, request.Commands...)
+	for _, line := range strings.Split(request.Script, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// Funcion para ejecutar una línea (comando o comentario) y devuelve su respuesta con el tiempo que tomó
+// Los comandos se ejecutan uno a la vez, junto con las operaciones de WebDAV y la API de archivos
+func runCommand(command string) CommandResponse {
+	filesMutex.Lock()
+	defer filesMutex.Unlock()
+	return executeLine(command)
+}
+
+// Funcion para ejecutar una línea, quien la llama debe tener filesMutex
+func executeLine(command string) CommandResponse {
+	//Antes de ejecutar el comando reviamos si esta linea es un comentario
+	//Los comentarios tendrán un # al inicio
+	if strings.HasPrefix(command, "#") {
+		log.Printf("Comentario: %s\n", command)
+		return CommandResponse{
+			Command: "Comentario",
+			Success: true,
+			Message: fmt.Sprintf("> Comentario: %s", command),
 		}
+	}
 
-		commandName, params := getCommandAndParams(command)
-		log.Println("Ejecutando comando:", commandName, "con parámetros:", params)
-		mensaje = fmt.Sprintf("> Comando %s con parámetros: %s ejecutado exitosamente", commandName, params)
-		particionesMontadasTxt := "\n> Particiones montadas:\n"
-		err = AnalyzeCommnad(commandName, params)
-		if err != nil {
-			if commandName == "mount" {
-				particionesMontadas := DiskManagement.GetMountedPartitions()
-				for _, particiones := range particionesMontadas {
-					for _, particion := range particiones {
-						particionesMontadasTxt += fmt.Sprintf("Path: %s, Name: %s, ID: %s, Status: %d\n", particion.Path, particion.Name, particion.ID, particion.Status)
+	inicio := time.Now()
+	commandName, params := getCommandAndParams(command)
+	log.Println("Ejecutando comando:", commandName, "con parámetros:", strings.TrimSpace(params))
+	mensaje := fmt.Sprintf("> Comando %s con parámetros: %s ejecutado exitosamente", commandName, strings.TrimSpace(params))
+	particionesMontadasTxt := "\n> Particiones montadas:\n"
+	result, err := AnalyzeCommnad(commandName, params)
+	response := CommandResponse{Command: commandName, Success: err == nil, Params: result.Params, Data: result.Data}
+	if err != nil {
+		response.Error = errorKind(err)
+		response.Code = errorCode(commandName, err)
+		if commandName == "mount" {
+			particionesMontadas := DiskManagement.GetMountedPartitions()
+			for _, particiones := range particionesMontadas {
+				for _, particion := range particiones {
+					particionesMontadasTxt += fmt.Sprintf("Path: %s, Name: %s, ID: %s, Status: %d\n", particion.Path, particion.Name, particion.ID, particion.Status)
 
-					}
 				}
-				//Devolvemos el mensaje de error y las particiones montadas
-				responses = append(responses, CommandResponse{
-					Command: commandName,
-					Message: fmt.Sprintf("> %s\n%s", err.Error(), particionesMontadasTxt),
-				})
-			} else {
-				responses = append(responses, CommandResponse{
-					Command: commandName,
-					Message: fmt.Sprintf("> %s", err.Error()),
-				})
 			}
+			//Devolvemos el mensaje de error y las particiones montadas
+			response.Message = fmt.Sprintf("> %s\n%s", err.Error(), particionesMontadasTxt)
 		} else {
-			if commandName == "mount" {
-				particionesMontadas := DiskManagement.GetMountedPartitions()
-				for _, particiones := range particionesMontadas {
-					for _, particion := range particiones {
+			response.Message = fmt.Sprintf("> %s", err.Error())
+		}
+	} else {
+		if commandName == "mount" {
+			particionesMontadas := DiskManagement.GetMountedPartitions()
+			for _, particiones := range particionesMontadas {
+				for _, particion := range particiones {
 
-						particionesMontadasTxt += fmt.Sprintf("\tPath: %s, Name: %s, ID: %s, Status: %d\n", particion.Path, particion.Name, particion.ID, particion.Status)
-					}
+					particionesMontadasTxt += fmt.Sprintf("\tPath: %s, Name: %s, ID: %s, Status: %d\n", particion.Path, particion.Name, particion.ID, particion.Status)
 				}
-
-				responses = append(responses, CommandResponse{
-					Command: commandName,
-					Message: fmt.Sprintf("%s\n%s", mensaje, particionesMontadasTxt),
-				})
-
-			} else {
-				responses = append(responses, CommandResponse{
-					Command: commandName,
-					Message: mensaje,
-				})
 			}
+			response.Message = fmt.Sprintf("%s\n%s", mensaje, particionesMontadasTxt)
+			if result.Output != "" {
+				response.Message += result.Output
+			}
+		} else if result.Output != "" {
+			response.Message = fmt.Sprintf("%s\n%s", mensaje, result.Output)
+		} else {
+			response.Message = mensaje
 		}
+	}
+	if commandName == "mount" && response.Data == nil {
+		response.Data = mountedPartitionsData()
+	}
+	response.DurationMs = float64(time.Since(inicio).Microseconds()) / 1000
+	return response
+}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(responses)
+// Funcion para obtener el tipo de error de un comando fallido
+func errorKind(err error) string {
+	switch {
+	case errors.Is(err, Errors.ErrNotFound):
+		return "not_found"
+	case errors.Is(err, Errors.ErrNoSpace):
+		return "no_space"
+	case errors.Is(err, Errors.ErrIO):
+		return "io"
+	case errors.Is(err, Errors.ErrCorrupt):
+		return "corrupt"
+	case errors.Is(err, Errors.ErrDenied):
+		return "denied"
+	default:
+		return "invalid"
 	}
 }
 
@@ -122,348 +194,597 @@ func ImprimirHandler(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintln(w, "Hola mundo")
 }
 
+// Funcion para separar el nombre del comando de sus parámetros
+// Los parámetros se devuelven tal como se escribieron, con el espacio que los separa del comando,
+// para que las columnas de los errores correspondan a la línea original
 func getCommandAndParams(input string) (string, string) {
-	parts := strings.Fields(input)
-	if len(parts) > 0 {
-		command := strings.ToLower(parts[0])
-		params := strings.Join(parts[1:], " ")
-		return command, params
+	input = strings.TrimSpace(input)
+	end := strings.IndexFunc(input, unicode.IsSpace)
+	if end == -1 {
+		return strings.ToLower(input), ""
 	}
-	return "", input
+	return strings.ToLower(input[:end]), input[end:]
 }
 
-func AnalyzeCommnad(command string, params string) error {
-	if strings.Contains(command, "mkdisk") {
-		return fn_mkdisk(params)
-	} else if strings.Contains(command, "rmdisk") {
-		return fn_rmdisk(params)
-	} else if strings.Contains(command, "fdisk") {
-		return fn_fdisk(params)
-	} else if strings.Contains(command, "mount") {
-		return fn_mount(params)
-	} else if strings.Contains(command, "rep") {
-		return fn_rep(params)
+// Funcion para ejecutar un comando, command es el nombre como se escribió (puede ser un alias)
+func AnalyzeCommnad(command string, params string) (CommandResult, error) {
+	// Los parámetros van separados del comando por al menos un espacio
+	if params != "" && !unicode.IsSpace(rune(params[0])) {
+		params = " " + params
+	}
+	definition, ok := lookupCommand(command)
+	if !ok {
+		return CommandResult{}, fmt.Errorf("Error: Comando %s inválido o no encontrado, use help para ver los comandos disponibles", command)
+	}
+
+	// Las escrituras del comando se registran para poder deshacerlo con undo
+	Utilities.BeginUndo(strings.TrimSpace(command + params))
+	defer Utilities.EndUndo()
+	var result CommandResult
+	var err error
+	if DiskManagement.DryRunActive() || (acceptsDryRun(definition) && requestsDryRun(params)) {
+		result, err = runDryRun(definition, command, params)
 	} else {
-		return fmt.Errorf("Error: Comando %s inválido o no encontrado", command)
+		result, err = definition.Handler(command, params)
+	}
+	if len(definition.Params) > 0 {
+		result.Params = paramValues(params)
 	}
+	return result, err
 }
 
-func fn_mkdisk(params string) error {
+func fn_mkdisk(command string, params string) (CommandResult, error) {
 	// Definir flag
-	fs := flag.NewFlagSet("mkdisk", flag.ExitOnError)
-	size := fs.Int("size", 0, "Tamaño")
-	fit := fs.String("fit", "ff", "Ajuste")
-	unit := fs.String("unit", "m", "Unidad")
-	path := fs.String("path", "", "Ruta")
+	fs := flagsFor(command)
+	size := fs.intParam("size")
+	fit := fs.stringParam("fit")
+	unit := fs.stringParam("unit")
+	path := fs.stringParam("path")
+	prealloc := fs.boolParam("prealloc")
 
-	// Encontrar la flag en el input
-	matches := re.FindAllStringSubmatch(params, -1)
-	for _, match := range matches {
-		flagName := match[1]
-		flagValue := strings.ToLower(match[2])
-		flagValue = strings.Trim(flagValue, "\"")
-		fs.Set(flagName, flagValue)
+	if err := parseParams(fs.FlagSet, params); err != nil {
+		return CommandResult{}, err
 	}
+	// Las opciones no distinguen mayúsculas, las rutas sí
+	*fit, *unit = strings.ToLower(*fit), strings.ToLower(*unit)
 
 	// Validaciones
 	if *size <= 0 {
-		return fmt.Errorf("Error: La cantidad debe ser mayor a 0")
+		return CommandResult{}, fmt.Errorf("Error: La cantidad debe ser mayor a 0")
 	}
 	if *fit != "bf" && *fit != "ff" && *fit != "wf" {
-		return fmt.Errorf("Error: El fit debe ser 'bf', 'ff', o 'wf'")
+		return CommandResult{}, fmt.Errorf("Error: El fit debe ser 'bf', 'ff', o 'wf'")
 	}
 	if *unit != "k" && *unit != "m" {
-		return fmt.Errorf("Error: Las unidades deben ser 'k' o 'm'")
-	}
-	if *path == "" {
-		return fmt.Errorf("Error: La ruta es obligatoria")
+		return CommandResult{}, fmt.Errorf("Error: Las unidades deben ser 'k' o 'm'")
 	}
 
 	// Llamar a la función
-	DiskManagement.Mkdisk(*size, *fit, *unit, *path)
-	return nil
+	err := DiskManagement.Mkdisk(*size, *fit, *unit, *path, *prealloc)
+	if err != nil {
+		return CommandResult{}, fmt.Errorf("Error: %w", err)
+	}
+	return CommandResult{}, nil
 }
 
-func fn_rmdisk(params string) error {
-	fs := flag.NewFlagSet("rmdisk", flag.ExitOnError)
-	path := fs.String("path", "", "Ruta")
+func fn_rmdisk(command string, params string) (CommandResult, error) {
+	fs := flagsFor(command)
+	path := fs.stringParam("path")
 
-	matches := re.FindAllStringSubmatch(params, -1)
-	for _, match := range matches {
-		flagName := match[1]
-		flagValue := strings.ToLower(match[2])
-		flagValue = strings.Trim(flagValue, "\"")
-		fs.Set(flagName, flagValue)
-	}
-
-	if *path == "" {
-		return fmt.Errorf("Error: La ruta es obligatoria")
+	if err := parseParams(fs.FlagSet, params); err != nil {
+		return CommandResult{}, err
 	}
 
 	err := DiskManagement.Rmdisk(*path)
 	if err != nil {
-		return fmt.Errorf("Error: %s", err.Error())
+		return CommandResult{}, fmt.Errorf("Error: %w", err)
 	}
-	return nil
+	return CommandResult{}, nil
 }
 
-func fn_fdisk(params string) error {
+func fn_fdisk(command string, params string) (CommandResult, error) {
 	// Definir flags
-	fs := flag.NewFlagSet("fdisk", flag.ExitOnError)
-	size := fs.Int("size", 0, "Tamaño")
-	path := fs.String("path", "", "Ruta")
-	name := fs.String("name", "", "Nombre")
-	unit := fs.String("unit", "k", "Unidad")
-	type_ := fs.String("type", "p", "Tipo")
-	fit := fs.String("fit", "", "Ajuste")
-
-	// Encontrar los flags en el input
-	matches := re.FindAllStringSubmatch(params, -1)
-	for _, match := range matches {
-		flagName := match[1]
-		flagValue := strings.ToLower(match[2])
-		flagValue = strings.Trim(flagValue, "\"")
-		fs.Set(flagName, flagValue)
+	fs := flagsFor(command)
+	size := fs.intParam("size")
+	path := fs.stringParam("path")
+	name := fs.stringParam("name")
+	unit := fs.stringParam("unit")
+	type_ := fs.stringParam("type")
+	fit := fs.stringParam("fit")
+
+	if err := parseParams(fs.FlagSet, params); err != nil {
+		return CommandResult{}, err
 	}
+	// Las opciones no distinguen mayúsculas, las rutas y nombres sí
+	*fit, *unit, *type_ = strings.ToLower(*fit), strings.ToLower(*unit), strings.ToLower(*type_)
 
 	// Validaciones
 	if *size <= 0 {
-		return fmt.Errorf("Error: Size debe ser mayor a 0")
-	}
-	if *path == "" {
-		return fmt.Errorf("Error: Path es obligatorio")
+		return CommandResult{}, fmt.Errorf("Error: Size debe ser mayor a 0")
 	}
 	if *fit == "" {
 		*fit = "wf"
 	}
 	if *fit != "bf" && *fit != "ff" && *fit != "wf" {
-		return fmt.Errorf("Error: Fit debe ser 'bf', 'ff', o 'wf'")
+		return CommandResult{}, fmt.Errorf("Error: Fit debe ser 'bf', 'ff', o 'wf'")
 	}
 	if *unit != "k" && *unit != "m" && *unit != "b" {
-		return fmt.Errorf("Error: Unidad debe ser 'k', 'm', o 'b'")
+		return CommandResult{}, fmt.Errorf("Error: Unidad debe ser 'k', 'm', o 'b'")
 	}
 	if *type_ != "p" && *type_ != "e" && *type_ != "l" {
-		return fmt.Errorf("Error: Tipo debe ser 'p', 'e', o 'l'")
-	}
-	if *name == "" {
-		return fmt.Errorf("Error: Name es obligatorio")
+		return CommandResult{}, fmt.Errorf("Error: Tipo debe ser 'p', 'e', o 'l'")
 	}
 
 	// Llamar a la función
 	err := DiskManagement.Fdisk(*size, *path, *name, *unit, *type_, *fit)
 	if err != nil {
-		return fmt.Errorf("Error: %s", err.Error())
+		return CommandResult{}, fmt.Errorf("Error: %w", err)
 	}
 
-	return nil
+	// La geometría de la partición creada se devuelve en data
+	var result CommandResult
+	if particion, err := partitionGeometry(*path, *name); err == nil {
+		result.Data = particion
+	}
+	return result, nil
 }
 
-func fn_mount(params string) error {
-	fs := flag.NewFlagSet("mount", flag.ExitOnError)
-	path := fs.String("path", "", "Ruta")
-	name := fs.String("name", "", "Nombre de la partición")
+func fn_mount(command string, params string) (CommandResult, error) {
+	fs := flagsFor(command)
+	path := fs.stringParam("path")
+	name := fs.stringParam("name")
 
-	fs.Parse(os.Args[1:])
-	matches := re.FindAllStringSubmatch(params, -1)
+	if err := parseParams(fs.FlagSet, params); err != nil {
+		return CommandResult{}, err
+	}
 
-	for _, match := range matches {
-		flagName := match[1]
-		flagValue := strings.ToLower(match[2])
-		flagValue = strings.Trim(flagValue, "\"")
-		fs.Set(flagName, flagValue)
+	err := DiskManagement.Mount(*path, *name)
+	if err != nil {
+		return CommandResult{}, fmt.Errorf("Error: %w", err)
 	}
+	return CommandResult{}, nil
+}
 
-	if *path == "" {
-		return fmt.Errorf("Error: Path es obligatorio")
+func fn_rep(command string, params string) (CommandResult, error) {
+	fs := flagsFor(command)
+	name := fs.stringParam("name")
+	path := fs.stringParam("path")
+	id := fs.stringParam("id")
+	path_file_ls := fs.stringParam("path_file_ls")
+
+	if err := parseParams(fs.FlagSet, params); err != nil {
+		return CommandResult{}, err
 	}
+	*name = strings.ToLower(*name)
 
-	if *name == "" {
-		return fmt.Errorf("Error: Name es obligatorio")
+	//Verificamos si la particion con la id dada esta montada
+	if _, _, err := DiskManagement.GetPartitionByID(*id); err != nil {
+		return CommandResult{}, fmt.Errorf("Error: %w", err)
 	}
 
-	// Convertir el nombre a minúsculas antes de pasarlo al Mount
-	nombreMinuscula := strings.ToLower(*name)
-	err := DiskManagement.Mount(*path, nombreMinuscula)
+	//El formato de salida se toma de la extensión de la ruta, el reporte file siempre es texto
+	if *name != "file" {
+		if _, err := Utilities.ReportFormat(*path); err != nil {
+			return CommandResult{}, fmt.Errorf("Error: %w", err)
+		}
+	}
+
+	datos, reporte, err := loadReport(*id, *name, *path_file_ls)
 	if err != nil {
-		return fmt.Errorf("Error: %s", err.Error())
+		return CommandResult{}, fmt.Errorf("Error: %w", err)
 	}
-	return nil
-}
 
-func fn_rep(params string) error {
-	fs := flag.NewFlagSet("rep", flag.ExitOnError)
-	name := fs.String("name", "", "Nombre")
-	path := fs.String("path", "", "Ruta")
-	id := fs.String("id", "", "ID")
-	path_file_ls := fs.String("path_file_ls", "", "Ruta del archivo")
+	// Con los discos en memoria no se escribe nada en el host, el reporte se devuelve en la respuesta
+	if Utilities.MemoryMode() {
+		return CommandResult{Output: fmt.Sprintf("> Reporte %s generado en memoria, no se escribió %s", *name, *path), Data: datos}, nil
+	}
 
-	matches := re.FindAllStringSubmatch(params, -1)
-	for _, match := range matches {
-		flagName := match[1]
-		flagValue := strings.ToLower(match[2])
-		flagValue = strings.Trim(flagValue, "\"")
-		fs.Set(flagName, flagValue)
+	reportsDir := filepath.Dir(*path)
+	if err := os.MkdirAll(reportsDir, os.ModePerm); err != nil {
+		return CommandResult{}, fmt.Errorf("Error: %w", err)
 	}
 
-	if *name == "" {
-		return fmt.Errorf("Error: Name es obligatorio")
+	if *name == "file" {
+		//El reporte file se escribe directamente con el contenido del archivo
+		if err := os.WriteFile(*path, []byte(datos.(FileData).Content), 0644); err != nil {
+			return CommandResult{}, fmt.Errorf("Error: %w", err)
+		}
+	} else if err := Utilities.RenderReport(reporte, *path); err != nil {
+		return CommandResult{}, fmt.Errorf("Error: %w", err)
 	}
-	if *path == "" {
-		return fmt.Errorf("Error: Path es obligatorio")
+	log.Printf("Reporte %s generado exitosamente en %s\n", *name, *path)
+
+	return CommandResult{Output: fmt.Sprintf("> Reporte generado en: %s", *path)}, nil
+}
+
+func fn_fsck(command string, params string) (CommandResult, error) {
+	fs := flagsFor(command)
+	id := fs.stringParam("id")
+	path := fs.stringParam("path")
+	repair := fs.boolParam("repair")
+
+	if err := parseParams(fs.FlagSet, params); err != nil {
+		return CommandResult{}, err
 	}
-	if *id == "" {
-		return fmt.Errorf("Error: ID es obligatorio")
+
+	if (*id == "") == (*path == "") {
+		return CommandResult{}, fmt.Errorf("Error: Debe indicar -id o -path, pero no ambos")
 	}
 
-	//Verificamos si la particion con la id dada esta montada
-	montada := false
-	var pathDisco string
-	for _, particiones := range DiskManagement.GetMountedPartitions() {
-		for _, particion := range particiones {
-			if particion.ID == *id {
-				montada = true
-				pathDisco = particion.Path
-			}
+	// Con -id se revisa el disco de la partición montada y su sistema de archivos,
+	// con -path el disco y todas sus particiones primarias formateadas
+	pathDisco := *path
+	var particiones []Structs.Partition
+	if *id != "" {
+		particion, ruta, err := DiskManagement.GetPartitionByID(*id)
+		if err != nil {
+			return CommandResult{}, fmt.Errorf("Error: %w", err)
 		}
+		pathDisco = ruta
+		particiones = append(particiones, particion)
 	}
 
-	if !montada {
-		return fmt.Errorf("Error: La partición con ID %s no está montada", *id)
+	issues, particionesMBR, err := DiskManagement.CheckDisk(pathDisco, *repair)
+	if err != nil {
+		return CommandResult{}, fmt.Errorf("Error: %w", err)
 	}
 
-	reportsDir := filepath.Dir(*path)
-	err := os.MkdirAll(reportsDir, os.ModePerm)
+	if *path != "" {
+		// Se usan las particiones como las revisó CheckDisk, así se revisan aunque el MBR esté dañado
+		for _, particion := range particionesMBR {
+			if particion.Size > 0 && particion.Type[0] == 'p' {
+				particiones = append(particiones, particion)
+			}
+		}
+	}
+
+	file, err := DiskManagement.OpenDisk(pathDisco)
 	if err != nil {
-		return fmt.Errorf("Error: %s", err.Error())
+		return CommandResult{}, fmt.Errorf("Error: %w", err)
 	}
+	defer file.Close()
 
-	switch *name {
-	case "mbr":
-		file, err := Utilities.OpenFile(pathDisco)
+	var notas []string
+	for _, particion := range particiones {
+		fsIssues, err := FileSystem.CheckFileSystem(file, particion, *repair)
 		if err != nil {
-			return fmt.Errorf("Error: %s", err.Error())
+			// Con -path las particiones sin formato no se revisan
+			if *path != "" && errors.Is(err, FileSystem.ErrNotFormatted) {
+				continue
+			}
+			notas = append(notas, fmt.Sprintf("Partición %s: %s", cleanBytes(particion.Name[:]), err.Error()))
+			continue
 		}
-		defer file.Close()
+		issues = append(issues, fsIssues...)
+	}
 
-		var TempMBR Structs.MRB
-		if err := Utilities.ReadObject(file, &TempMBR, 0); err != nil {
-			return fmt.Errorf("Error: %s", err.Error())
-		}
+	return CommandResult{
+		Output: fsckReport(pathDisco, issues, notas),
+		Data:   FsckData{Disk: pathDisco, Issues: append([]Errors.FsckIssue{}, issues...)},
+	}, nil
+}
 
-		var ebrs []Structs.EBR
-		for i := 0; i < 4; i++ {
-			if string(TempMBR.Partitions[i].Type[:]) == "e" {
-				log.Println("Partición extendida encontrada", string(TempMBR.Partitions[i].Name[:]))
+func fn_dumplayout(command string, params string) (CommandResult, error) {
+	fs := flagsFor(command)
+	path := fs.stringParam("path")
+	out := fs.stringParam("out")
 
-				ebrPosition := TempMBR.Partitions[i].Start
-				ebrCounter := 1
+	if err := parseParams(fs.FlagSet, params); err != nil {
+		return CommandResult{}, err
+	}
 
-				//Leemos todos los ebrs de la partición extendida
-				for ebrPosition != -1 {
-					log.Println("Leyendo EBR en posicion", ebrPosition)
-					var TempEBR Structs.EBR
-					if err := Utilities.ReadObject(file, &TempEBR, int64(ebrPosition)); err != nil {
-						return fmt.Errorf("Error: %s", err.Error())
-					}
+	layout, err := DiskManagement.DumpLayout(*path)
+	if err != nil {
+		return CommandResult{}, fmt.Errorf("Error: %w", err)
+	}
 
-					ebrs = append(ebrs, TempEBR)
-					Structs.PrintEBR(TempEBR)
+	datos, err := json.MarshalIndent(layout, "", "  ")
+	if err != nil {
+		return CommandResult{}, fmt.Errorf("Error: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(*out), os.ModePerm); err != nil {
+		return CommandResult{}, fmt.Errorf("Error: %w", err)
+	}
+	if err := os.WriteFile(*out, append(datos, '\n'), 0644); err != nil {
+		return CommandResult{}, fmt.Errorf("Error: %w", err)
+	}
 
-					ebrPosition = TempEBR.PartNext
-					ebrCounter++
+	return CommandResult{Output: fmt.Sprintf("> Distribución guardada en: %s", *out), Data: layout}, nil
+}
 
-					if ebrPosition == -1 {
-						break
-					}
-				}
-			}
+func fn_restorelayout(command string, params string) (CommandResult, error) {
+	fs := flagsFor(command)
+	path := fs.stringParam("path")
+	in := fs.stringParam("in")
 
-		}
+	if err := parseParams(fs.FlagSet, params); err != nil {
+		return CommandResult{}, err
+	}
 
-		pathReporte := *path
-		if err := Utilities.GenerateReportMBR(TempMBR, ebrs, pathReporte, file); err != nil {
-			return fmt.Errorf("Error: %s", err.Error())
-		} else {
-			log.Println("Reporte MBR generado exitosamente")
-			dotFile := strings.TrimSuffix(pathReporte, filepath.Ext(pathReporte)) + ".dot"
-			outupPng := strings.TrimSuffix(pathReporte, filepath.Ext(pathReporte)) + ".png"
-
-			cmd := exec.Command("dot", "-Tpng", dotFile, "-o", outupPng)
-			err := cmd.Run()
-			if err != nil {
-				return fmt.Errorf("Error: %s", err.Error())
-			} else {
-				log.Println("Imagen generada exitosamente")
-			}
-		}
+	datos, err := os.ReadFile(*in)
+	if err != nil {
+		return CommandResult{}, fmt.Errorf("Error: No se pudo leer la distribución %s: %w", *in, err)
+	}
+	var layout DiskManagement.Layout
+	if err := json.Unmarshal(datos, &layout); err != nil {
+		return CommandResult{}, fmt.Errorf("Error: La distribución %s no es un JSON válido: %w", *in, err)
+	}
+
+	if err := DiskManagement.RestoreLayout(*path, layout); err != nil {
+		return CommandResult{}, fmt.Errorf("Error: %w", err)
+	}
+	return CommandResult{}, nil
+}
 
-	case "disk":
-		//Generamos el reporte del disco
-		file, err := Utilities.OpenFile(pathDisco)
+func fn_clonedisk(command string, params string) (CommandResult, error) {
+	fs := flagsFor(command)
+	path := fs.stringParam("path")
+	dest := fs.stringParam("dest")
 
-		fileName := filepath.Base(pathDisco)
+	if err := parseParams(fs.FlagSet, params); err != nil {
+		return CommandResult{}, err
+	}
 
+	if err := DiskManagement.CloneDisk(*path, *dest); err != nil {
+		return CommandResult{}, fmt.Errorf("Error: %w", err)
+	}
+	return CommandResult{Output: fmt.Sprintf("> Disco copiado en: %s", *dest)}, nil
+}
 
-		if err != nil {
-			return fmt.Errorf("Error: %s", err.Error())
-		}
-		defer file.Close()
+func fn_snapshot(command string, params string) (CommandResult, error) {
+	fs := flagsFor(command)
+	path := fs.stringParam("path")
+	tag := fs.stringParam("tag")
 
-		var TempMBR Structs.MRB
-		if err := Utilities.ReadObject(file, &TempMBR, 0); err != nil {
-			return fmt.Errorf("Error: %s", err.Error())
-		}
+	if err := parseParams(fs.FlagSet, params); err != nil {
+		return CommandResult{}, err
+	}
 
-		var ebrs []Structs.EBR
-		for i := 0; i < 4; i++ {
-			if string(TempMBR.Partitions[i].Type[:]) == "e" {
-				log.Println("Partición extendida encontrada", string(TempMBR.Partitions[i].Name[:]))
+	if err := DiskManagement.Snapshot(*path, *tag); err != nil {
+		return CommandResult{}, fmt.Errorf("Error: %w", err)
+	}
+	return CommandResult{}, nil
+}
 
-				ebrPosition := TempMBR.Partitions[i].Start
+func fn_restore(command string, params string) (CommandResult, error) {
+	fs := flagsFor(command)
+	path := fs.stringParam("path")
+	tag := fs.stringParam("tag")
 
-				//Leemos todos los ebrs de la partición extendida
-				for ebrPosition != -1 {
-					log.Println("Leyendo EBR en posicion", ebrPosition)
-					var TempEBR Structs.EBR
-					if err := Utilities.ReadObject(file, &TempEBR, int64(ebrPosition)); err != nil {
-						return fmt.Errorf("Error: %s", err.Error())
-					}
+	if err := parseParams(fs.FlagSet, params); err != nil {
+		return CommandResult{}, err
+	}
 
-					ebrs = append(ebrs, TempEBR)
+	if err := DiskManagement.Restore(*path, *tag); err != nil {
+		return CommandResult{}, fmt.Errorf("Error: %w", err)
+	}
+	return CommandResult{}, nil
+}
 
-					ebrPosition = TempEBR.PartNext
+func fn_undo(command string, params string) (CommandResult, error) {
+	fs := flagsFor(command)
+	path := fs.stringParam("path")
+	steps := fs.intParam("steps")
 
-					if ebrPosition == -1 {
-						break
-					}
-				}
-			}
+	if err := parseParams(fs.FlagSet, params); err != nil {
+		return CommandResult{}, err
+	}
 
+	undone, err := DiskManagement.Undo(*path, *steps)
+	data := []UndoData{}
+	salida := []string{fmt.Sprintf("> Deshecho(s) %d comando(s) en %s:", len(undone), *path)}
+	for _, entry := range undone {
+		undo := UndoData{Command: entry.Command, Writes: len(entry.Writes)}
+		for _, write := range entry.Writes {
+			undo.Bytes += len(write.Old)
+		}
+		data = append(data, undo)
+		salida = append(salida, fmt.Sprintf("  %s (%d escritura(s), %d bytes)", undo.Command, undo.Writes, undo.Bytes))
+	}
+	// Si se deshicieron algunos comandos antes del error también se devuelven
+	result := CommandResult{Output: strings.Join(salida, "\n"), Data: data}
+	if err != nil {
+		if len(undone) > 0 {
+			return result, fmt.Errorf("Error: %w\n%s", err, result.Output)
 		}
+		return result, fmt.Errorf("Error: %w", err)
+	}
+	return result, nil
+}
 
-		totalDiskSize := TempMBR.MbrSize
-		pathReporte := *path
-		if err := Utilities.GenerateReportDisk(TempMBR, ebrs, pathReporte, file, totalDiskSize, fileName); err != nil {
-			return fmt.Errorf("Error: %s", err.Error())
-		} else {
-			log.Println("Reporte Disk generado exitosamente")
-			dotFile := strings.TrimSuffix(pathReporte, filepath.Ext(pathReporte)) + ".dot"
-			outupPng := strings.TrimSuffix(pathReporte, filepath.Ext(pathReporte)) + ".png"
-
-			cmd := exec.Command("dot", "-Tpng", dotFile, "-o", outupPng)
-			err := cmd.Run()
-			if err != nil {
-				return fmt.Errorf("Error: %s", err.Error())
-			} else {
-				log.Println("Imagen generada exitosamente")
-			}
+func fn_import(command string, params string) (CommandResult, error) {
+	fs := flagsFor(command)
+	id := fs.stringParam("id")
+	src := fs.stringParam("src")
+	dest := fs.stringParam("dest")
+
+	if err := parseParams(fs.FlagSet, params); err != nil {
+		return CommandResult{}, err
+	}
+
+	if !strings.HasPrefix(*dest, "/") {
+		return CommandResult{}, fmt.Errorf("Error: Dest debe ser una ruta absoluta")
+	}
+
+	particion, pathDisco, err := DiskManagement.GetPartitionByID(*id)
+	if err != nil {
+		return CommandResult{}, fmt.Errorf("Error: %w", err)
+	}
+	file, err := DiskManagement.OpenDisk(pathDisco)
+	if err != nil {
+		return CommandResult{}, fmt.Errorf("Error: %w", err)
+	}
+	defer file.Close()
+
+	resultado, err := FileSystem.Import(file, particion, *src, *dest, sessionUID, sessionGID)
+	resumen := fmt.Sprintf("%d carpeta(s), %d archivo(s), %d bytes", resultado.Directories, resultado.Files, resultado.Bytes)
+	omitidos := ""
+	if len(resultado.Skipped) > 0 {
+		omitidos = fmt.Sprintf("\n> Omitido(s) %d elemento(s):\n  %s", len(resultado.Skipped), strings.Join(resultado.Skipped, "\n  "))
+	}
+	if err != nil {
+		return CommandResult{}, fmt.Errorf("Error: %w (se importaron %s antes del error)%s", err, resumen, omitidos)
+	}
+	return CommandResult{Output: fmt.Sprintf("> Importado %s en %s: %s%s", *src, *dest, resumen, omitidos)}, nil
+}
+
+func fn_export(command string, params string) (CommandResult, error) {
+	fs := flagsFor(command)
+	id := fs.stringParam("id")
+	path := fs.stringParam("path")
+	out := fs.stringParam("out")
+
+	if err := parseParams(fs.FlagSet, params); err != nil {
+		return CommandResult{}, err
+	}
+
+	if !strings.HasPrefix(*path, "/") {
+		return CommandResult{}, fmt.Errorf("Error: Path debe ser una ruta absoluta")
+	}
+
+	particion, pathDisco, err := DiskManagement.GetPartitionByID(*id)
+	if err != nil {
+		return CommandResult{}, fmt.Errorf("Error: %w", err)
+	}
+	file, err := DiskManagement.OpenDisk(pathDisco)
+	if err != nil {
+		return CommandResult{}, fmt.Errorf("Error: %w", err)
+	}
+	defer file.Close()
+
+	resultado, err := FileSystem.Export(file, particion, *path, *out)
+	if err != nil {
+		return CommandResult{}, fmt.Errorf("Error: %w", err)
+	}
+	return CommandResult{Output: fmt.Sprintf("> Exportado %s en %s: %d carpeta(s), %d archivo(s), %d bytes", *path, *out, resultado.Directories, resultado.Files, resultado.Bytes)}, nil
+}
+
+// Scripts que se están ejecutando, para evitar que un script se ejecute a sí mismo
+var executingScripts = make(map[string]bool)
+
+// Extensión de los scripts que acepta execute
+const scriptExtension = ".smia"
+
+// Carpeta de la que execute puede leer scripts, se configura con MIA_SCRIPTS, vacía para aceptar cualquier carpeta
+var ScriptsDir string
+
+// Funcion para obtener la ruta real de un script, validando su extensión y que esté dentro de ScriptsDir
+// Así execute no sirve para leer por HTTP cualquier archivo al que tenga acceso el servidor
+func scriptPath(path string) (string, error) {
+	if !strings.EqualFold(filepath.Ext(path), scriptExtension) {
+		return "", &Errors.DiskError{Kind: Errors.ErrInvalid, Message: fmt.Sprintf("El script %s debe tener extensión %s", path, scriptExtension)}
+	}
+	absPath, err := filepath.Abs(path)
+	if err == nil {
+		absPath, err = filepath.EvalSymlinks(absPath)
+	}
+	if err != nil {
+		return "", &Errors.DiskError{Kind: Errors.ErrNotFound, Message: fmt.Sprintf("No se pudo leer el script %s", path), Err: err}
+	}
+	if ScriptsDir == "" {
+		return absPath, nil
+	}
+
+	dir, err := filepath.Abs(ScriptsDir)
+	if err == nil {
+		dir, err = filepath.EvalSymlinks(dir)
+	}
+	if err != nil {
+		return "", &Errors.DiskError{Kind: Errors.ErrIO, Message: fmt.Sprintf("No se pudo leer la carpeta de scripts %s", ScriptsDir), Err: err}
+	}
+	if rel, err := filepath.Rel(dir, absPath); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", &Errors.DiskError{Kind: Errors.ErrDenied, Message: fmt.Sprintf("El script %s no está en la carpeta de scripts %s", path, ScriptsDir)}
+	}
+	return absPath, nil
+}
+
+func fn_execute(command string, params string) (CommandResult, error) {
+	fs := flagsFor(command)
+	path := fs.stringParam("path")
+
+	if err := parseParams(fs.FlagSet, params); err != nil {
+		return CommandResult{}, err
+	}
+
+	absPath, err := scriptPath(*path)
+	if err != nil {
+		return CommandResult{}, fmt.Errorf("Error: %w", err)
+	}
+	if executingScripts[absPath] {
+		return CommandResult{}, fmt.Errorf("Error: El script %s ya se está ejecutando, no puede ejecutarse a sí mismo", *path)
+	}
+	contenido, err := os.ReadFile(absPath)
+	if err != nil {
+		return CommandResult{}, fmt.Errorf("Error: %w", &Errors.DiskError{Kind: Errors.ErrNotFound, Message: fmt.Sprintf("No se pudo leer el script %s", *path), Err: err})
+	}
+	executingScripts[absPath] = true
+	defer delete(executingScripts, absPath)
+
+	var salida []string
+	exitosos, fallidos := 0, 0
+	for numero, linea := range strings.Split(string(contenido), "\n") {
+		linea = strings.TrimSpace(linea)
+		if linea == "" || strings.HasPrefix(linea, "#") {
+			continue
 		}
 
-		log.Println(path_file_ls)
+		// Las líneas que no empiezan con un comando no se muestran, el archivo podría no ser un script
+		nombre, parametros := getCommandAndParams(linea)
+		if _, ok := lookupCommand(nombre); !ok {
+			fallidos++
+			salida = append(salida, fmt.Sprintf("> [línea %d] Error: la línea no es un comando válido", numero+1))
+			continue
+		}
+		salida = append(salida, fmt.Sprintf("> [línea %d] %s", numero+1, linea))
+		resultado, err := AnalyzeCommnad(nombre, parametros)
+		if err != nil {
+			fallidos++
+			salida = append(salida, fmt.Sprintf("> %s", err.Error()))
+			continue
+		}
+		exitosos++
+		if resultado.Output != "" {
+			salida = append(salida, resultado.Output)
+		}
+	}
 
-	default:
-		return fmt.Errorf("Error: Reporte %s no encontrado", *name)
+	resumen := fmt.Sprintf("> Resumen de %s: %d comando(s) exitoso(s), %d fallido(s)", *path, exitosos, fallidos)
+	salida = append(salida, resumen)
+	result := CommandResult{Output: strings.Join(salida, "\n")}
+	if fallidos > 0 {
+		return result, fmt.Errorf("Error: %d de %d comando(s) del script fallaron\n%s", fallidos, exitosos+fallidos, result.Output)
 	}
+	return result, nil
+}
 
-	return nil
+// Funcion para armar el reporte de fsck, una sección por verificación y un resumen al final
+func fsckReport(pathDisco string, issues []Errors.FsckIssue, notas []string) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "> Verificación del disco %s\n", pathDisco)
+
+	reparados := 0
+	for _, check := range Errors.FsckChecks {
+		var lineas []string
+		for _, issue := range issues {
+			if issue.Check != check {
+				continue
+			}
+			estado := ""
+			if issue.Repaired {
+				estado = " [reparado]"
+				reparados++
+			}
+			lineas = append(lineas, fmt.Sprintf("\t- %s%s", issue.Message, estado))
+		}
+		if len(lineas) == 0 {
+			fmt.Fprintf(&builder, "%s: OK\n", check)
+			continue
+		}
+		fmt.Fprintf(&builder, "%s: %d problema(s)\n%s\n", check, len(lineas), strings.Join(lineas, "\n"))
+	}
+	for _, nota := range notas {
+		fmt.Fprintf(&builder, "%s\n", nota)
+	}
+	fmt.Fprintf(&builder, "> Resumen: %d problema(s) encontrado(s), %d reparado(s)", len(issues), reparados)
+	return builder.String()
 }
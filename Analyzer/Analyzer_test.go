@@ -0,0 +1,79 @@
+package Analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"proyecto1/DiskManagement"
+	"proyecto1/Utilities"
+	"strings"
+	"testing"
+)
+
+func TestMemoryModeScript(t *testing.T) {
+	Utilities.SetMemoryMode(true)
+	t.Cleanup(func() {
+		DiskManagement.Clean()
+		Utilities.SetMemoryMode(false)
+	})
+
+	dir := t.TempDir()
+	disk := filepath.Join(dir, "discos", "a.mia")
+	report := filepath.Join(dir, "reportes", "mbr.png")
+	for _, line := range []struct{ command, params string }{
+		{"mkdisk", "-size=1 -unit=m -path=" + disk},
+		{"fdisk", "-size=100 -path=" + disk + " -name=p1"},
+		{"mount", "-path=" + disk + " -name=p1"},
+	} {
+		if _, err := AnalyzeCommnad(line.command, line.params); err != nil {
+			t.Fatalf("%s: %v", line.command, err)
+		}
+	}
+
+	var id string
+	for _, partitions := range DiskManagement.GetMountedPartitions() {
+		for _, partition := range partitions {
+			if partition.Path == disk {
+				id = partition.ID
+			}
+		}
+	}
+	if id == "" {
+		t.Fatal("La partición p1 no quedó montada")
+	}
+
+	result, err := AnalyzeCommnad("rep", "-name=mbr -path="+report+" -id="+id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Data == nil {
+		t.Error("El reporte en memoria debe devolverse en la respuesta")
+	}
+
+	// Ni el disco ni el reporte ni sus carpetas se crean en el host
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		t.Errorf("Se creó %s en el host", filepath.Join(dir, entry.Name()))
+	}
+}
+
+func TestHelpListsDryRunCommands(t *testing.T) {
+	result, err := AnalyzeCommnad("help", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dryRun string
+	for _, line := range strings.Split(result.Output, "\n") {
+		if strings.HasPrefix(line, "> Aceptan -dryrun") {
+			dryRun = line
+		}
+	}
+	for _, command := range commands {
+		listed := strings.Contains(dryRun, " "+command.Name+",") || strings.HasSuffix(dryRun, " "+command.Name)
+		if listed != (acceptsDryRun(command) && !command.WritesHost) {
+			t.Errorf("%s: la ayuda de -dryrun no coincide con el registro: %q", command.Name, dryRun)
+		}
+	}
+}
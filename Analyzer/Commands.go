@@ -0,0 +1,369 @@
+package Analyzer
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Tipo del valor de un parámetro, los booleanos pueden ir sin valor
+type ParamKind int
+
+const (
+	paramString ParamKind = iota
+	paramInt
+	paramBool
+)
+
+// Parámetro que acepta un comando
+// Default es el valor que toma si no se indica, escrito como en la línea del comando
+type ParamSpec struct {
+	Name        string
+	Required    bool
+	Kind        ParamKind
+	Default     string
+	Description string
+}
+
+// Declaración de un comando, el analizador lo busca por su nombre exacto o por uno de sus alias
+// WritesHost indica que el comando escribe archivos en el sistema anfitrión, con -dryrun no se ejecuta
+type Command struct {
+	Name        string
+	Aliases     []string
+	Description string
+	Params      []ParamSpec
+	Handler     func(command string, params string) (CommandResult, error)
+	WritesHost  bool
+}
+
+// Comandos disponibles, en el orden en que se muestran en help
+// Se asignan en init porque help necesita consultar la lista
+var commands []Command
+
+func init() {
+	commands = []Command{
+		{
+			Name:        "mkdisk",
+			Description: "Crea un disco virtual con su MBR",
+			Params: []ParamSpec{
+				{Name: "size", Required: true, Kind: paramInt, Description: "Tamaño del disco, mayor a 0"},
+				{Name: "fit", Default: "ff", Description: "Ajuste de las particiones: bf, ff o wf"},
+				{Name: "unit", Default: "m", Description: "Unidad del tamaño: k o m"},
+				{Name: "path", Required: true, Description: "Ruta del disco"},
+				{Name: "prealloc", Kind: paramBool, Description: "Reserva todo el espacio del disco al crearlo, va sin valor"},
+			},
+			Handler: fn_mkdisk,
+		},
+		{
+			Name:        "rmdisk",
+			Description: "Elimina un disco virtual",
+			Params: []ParamSpec{
+				{Name: "path", Required: true, Description: "Ruta del disco"},
+			},
+			Handler: fn_rmdisk,
+		},
+		{
+			Name:        "fdisk",
+			Description: "Crea una partición primaria, extendida o lógica en un disco",
+			Params: []ParamSpec{
+				{Name: "size", Required: true, Kind: paramInt, Description: "Tamaño de la partición, mayor a 0"},
+				{Name: "path", Required: true, Description: "Ruta del disco"},
+				{Name: "name", Required: true, Description: "Nombre de la partición"},
+				{Name: "unit", Default: "k", Description: "Unidad del tamaño: b, k o m"},
+				{Name: "type", Default: "p", Description: "Tipo de partición: p, e o l"},
+				{Name: "fit", Description: "Ajuste de la partición: bf, ff o wf (wf por defecto)"},
+			},
+			Handler: fn_fdisk,
+		},
+		{
+			Name:        "mount",
+			Description: "Monta una partición primaria y le asigna un ID",
+			Params: []ParamSpec{
+				{Name: "path", Required: true, Description: "Ruta del disco"},
+				{Name: "name", Required: true, Description: "Nombre de la partición"},
+			},
+			Handler: fn_mount,
+		},
+		{
+			Name:        "rep",
+			Aliases:     []string{"report"},
+			Description: "Genera un reporte de una partición montada",
+			Params: []ParamSpec{
+				{Name: "name", Required: true, Description: "Reporte: " + strings.Join(reportNames, ", ")},
+				{Name: "path", Required: true, Description: "Archivo de salida, el formato se toma de la extensión"},
+				{Name: "id", Required: true, Description: "ID de la partición montada"},
+				{Name: "path_file_ls", Description: "Ruta dentro de la partición para los reportes file y ls"},
+			},
+			Handler:    fn_rep,
+			WritesHost: true,
+		},
+		{
+			Name:        "fsck",
+			Description: "Verifica y opcionalmente repara un disco y sus sistemas de archivos",
+			Params: []ParamSpec{
+				{Name: "id", Description: "ID de una partición montada, se usa -id o -path"},
+				{Name: "path", Description: "Ruta del disco, se usa -id o -path"},
+				{Name: "repair", Kind: paramBool, Description: "Repara los problemas encontrados, va sin valor"},
+			},
+			Handler: fn_fsck,
+		},
+		{
+			Name:        "dumplayout",
+			Description: "Guarda la distribución de particiones de un disco en un archivo JSON",
+			Params: []ParamSpec{
+				{Name: "path", Required: true, Description: "Ruta del disco"},
+				{Name: "out", Required: true, Description: "Archivo JSON de salida"},
+			},
+			Handler:    fn_dumplayout,
+			WritesHost: true,
+		},
+		{
+			Name:        "restorelayout",
+			Description: "Escribe en un disco la distribución de particiones de un archivo JSON",
+			Params: []ParamSpec{
+				{Name: "path", Required: true, Description: "Ruta del disco, se crea si no existe"},
+				{Name: "in", Required: true, Description: "Archivo JSON con la distribución"},
+			},
+			Handler: fn_restorelayout,
+		},
+		{
+			Name:        "clonedisk",
+			Description: "Copia un disco a una ruta nueva con una firma nueva",
+			Params: []ParamSpec{
+				{Name: "path", Required: true, Description: "Ruta del disco"},
+				{Name: "dest", Required: true, Description: "Ruta del disco nuevo, no debe existir"},
+			},
+			Handler: fn_clonedisk,
+		},
+		{
+			Name:        "snapshot",
+			Description: "Guarda una copia del disco con un tag",
+			Params: []ParamSpec{
+				{Name: "path", Required: true, Description: "Ruta del disco"},
+				{Name: "tag", Required: true, Description: "Nombre de la copia"},
+			},
+			Handler: fn_snapshot,
+		},
+		{
+			Name:        "restore",
+			Description: "Restaura un disco desde una copia guardada con snapshot",
+			Params: []ParamSpec{
+				{Name: "path", Required: true, Description: "Ruta del disco"},
+				{Name: "tag", Required: true, Description: "Nombre de la copia"},
+			},
+			Handler: fn_restore,
+		},
+		{
+			Name:        "undo",
+			Description: "Deshace los últimos comandos que escribieron en un disco, solo los de la sesión actual del servidor (el registro no se guarda al reiniciar)",
+			Params: []ParamSpec{
+				{Name: "path", Required: true, Description: "Ruta del disco"},
+				{Name: "steps", Kind: paramInt, Default: "1", Description: "Cantidad de comandos a deshacer"},
+			},
+			Handler: fn_undo,
+		},
+		{
+			Name:        "import",
+			Description: "Copia una carpeta del sistema anfitrión a una partición montada, los archivos quedan a nombre de root (UID 1, GID 1) porque todavía no hay sesiones",
+			Params: []ParamSpec{
+				{Name: "id", Required: true, Description: "ID de la partición montada"},
+				{Name: "src", Required: true, Description: "Carpeta del sistema anfitrión"},
+				{Name: "dest", Required: true, Description: "Ruta absoluta destino en la partición"},
+			},
+			Handler: fn_import,
+		},
+		{
+			Name:        "export",
+			Description: "Copia una ruta de una partición montada al sistema anfitrión",
+			Params: []ParamSpec{
+				{Name: "id", Required: true, Description: "ID de la partición montada"},
+				{Name: "path", Default: "/", Description: "Ruta absoluta en la partición"},
+				{Name: "out", Required: true, Description: "Carpeta de salida o archivo .tar"},
+			},
+			Handler:    fn_export,
+			WritesHost: true,
+		},
+		{
+			Name:        "execute",
+			Aliases:     []string{"exec"},
+			Description: "Ejecuta un script de comandos guardado en el servidor",
+			Params: []ParamSpec{
+				{Name: "path", Required: true, Description: "Ruta del script .smia, dentro de MIA_SCRIPTS si está configurada"},
+			},
+			Handler: fn_execute,
+		},
+		{
+			Name:        "help",
+			Aliases:     []string{"ayuda"},
+			Description: "Muestra los comandos disponibles o la ayuda de un comando (help <comando>)",
+			Handler:     fn_help,
+		},
+	}
+
+	// Un valor por defecto inválido en la declaración es un error de programación, se detecta al iniciar
+	for _, command := range commands {
+		newCommandFlags(command)
+	}
+}
+
+// Parámetros de un comando ya definidos en un FlagSet, con los valores que se asignan al analizarlos
+type commandFlags struct {
+	*flag.FlagSet
+	values map[string]interface{}
+}
+
+// Funcion para crear el FlagSet de un comando a partir de sus parámetros declarados
+// Así la declaración es la única lista de parámetros, la usan help, la validación y el handler
+func newCommandFlags(command Command) *commandFlags {
+	flags := &commandFlags{FlagSet: flag.NewFlagSet(command.Name, flag.ContinueOnError), values: make(map[string]interface{})}
+	for _, param := range command.Params {
+		switch param.Kind {
+		case paramInt:
+			value := 0
+			if param.Default != "" {
+				var err error
+				if value, err = strconv.Atoi(param.Default); err != nil {
+					panic(fmt.Sprintf("valor por defecto inválido para -%s de %s: %v", param.Name, command.Name, err))
+				}
+			}
+			flags.values[param.Name] = flags.Int(param.Name, value, param.Description)
+		case paramBool:
+			flags.values[param.Name] = flags.Bool(param.Name, param.Default == "true", param.Description)
+		default:
+			flags.values[param.Name] = flags.String(param.Name, param.Default, param.Description)
+		}
+	}
+	return flags
+}
+
+// Funcion para obtener los parámetros declarados de un comando registrado
+// El FlagSet toma el nombre como se escribió (puede ser un alias), las columnas de los errores se cuentan desde él
+func flagsFor(name string) *commandFlags {
+	command, ok := lookupCommand(name)
+	if !ok {
+		panic(fmt.Sprintf("comando %s no declarado", name))
+	}
+	flags := newCommandFlags(command)
+	flags.Init(name, flag.ContinueOnError)
+	return flags
+}
+
+// Funciones para obtener el valor de un parámetro declarado, un nombre o tipo distinto a la declaración es un error de programación
+func (f *commandFlags) stringParam(name string) *string {
+	return f.param(name).(*string)
+}
+
+func (f *commandFlags) intParam(name string) *int {
+	return f.param(name).(*int)
+}
+
+func (f *commandFlags) boolParam(name string) *bool {
+	return f.param(name).(*bool)
+}
+
+func (f *commandFlags) param(name string) interface{} {
+	value, ok := f.values[name]
+	if !ok {
+		panic(fmt.Sprintf("el parámetro -%s no está declarado en %s", name, f.Name()))
+	}
+	return value
+}
+
+// Funcion para buscar un comando por su nombre o alias exacto, sin distinguir mayúsculas
+func lookupCommand(name string) (Command, bool) {
+	name = strings.ToLower(name)
+	for _, command := range commands {
+		if command.Name == name {
+			return command, true
+		}
+		for _, alias := range command.Aliases {
+			if alias == name {
+				return command, true
+			}
+		}
+	}
+	return Command{}, false
+}
+
+// Funcion para saber si un comando acepta -dryrun, los que no declaran parámetros lo reciben como texto
+func acceptsDryRun(command Command) bool {
+	return len(command.Params) > 0
+}
+
+// help recibe como parámetro el nombre de un comando, sin guion
+func fn_help(command string, params string) (CommandResult, error) {
+	name := strings.TrimSpace(params)
+	if name == "" {
+		var builder strings.Builder
+		builder.WriteString("> Comandos disponibles:\n")
+		for _, command := range commands {
+			fmt.Fprintf(&builder, "  %-14s %s\n", command.Name, command.Description)
+		}
+		builder.WriteString("> Use help <comando> para ver sus parámetros\n")
+		var dryRun, skipped []string
+		for _, command := range commands {
+			if acceptsDryRun(command) && command.WritesHost {
+				skipped = append(skipped, command.Name)
+			} else if acceptsDryRun(command) {
+				dryRun = append(dryRun, command.Name)
+			}
+		}
+		fmt.Fprintf(&builder, "> Aceptan -dryrun para ver los cambios que harían en los discos sin escribirlos: %s\n", strings.Join(dryRun, ", "))
+		fmt.Fprintf(&builder, "> Con -dryrun no se ejecutan %s, escriben archivos en el sistema anfitrión", strings.Join(skipped, ", "))
+		return CommandResult{Output: builder.String()}, nil
+	}
+
+	definition, ok := lookupCommand(name)
+	if !ok {
+		return CommandResult{}, fmt.Errorf("Error: Comando %s inválido o no encontrado, use help para ver los comandos disponibles", name)
+	}
+	return CommandResult{Output: commandHelp(definition)}, nil
+}
+
+// Funcion para armar la ayuda de un comando a partir de su declaración
+func commandHelp(command Command) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "> %s: %s\n", command.Name, command.Description)
+	if len(command.Aliases) > 0 {
+		aliases := append([]string{}, command.Aliases...)
+		sort.Strings(aliases)
+		fmt.Fprintf(&builder, "  Alias: %s\n", strings.Join(aliases, ", "))
+	}
+
+	usage := command.Name
+	for _, param := range command.Params {
+		if param.Required {
+			usage += fmt.Sprintf(" -%s=valor", param.Name)
+		}
+	}
+	if len(command.Params) > len(requiredParams(command)) {
+		usage += " [opciones]"
+	}
+	fmt.Fprintf(&builder, "  Uso: %s", usage)
+
+	for _, param := range command.Params {
+		kind := "opcional"
+		if param.Required {
+			kind = "obligatorio"
+		}
+		description := param.Description
+		if param.Default != "" {
+			description += fmt.Sprintf(" (%s por defecto)", param.Default)
+		}
+		fmt.Fprintf(&builder, "\n    -%-14s %-12s %s", param.Name, kind, description)
+	}
+	return builder.String()
+}
+
+// Funcion para obtener los nombres de los parámetros obligatorios de un comando
+func requiredParams(command Command) []string {
+	var required []string
+	for _, param := range command.Params {
+		if param.Required {
+			required = append(required, param.Name)
+		}
+	}
+	return required
+}
@@ -0,0 +1,138 @@
+package Analyzer
+
+import (
+	"context"
+	"fmt"
+	"proyecto1/DiskManagement"
+	"strings"
+	"time"
+)
+
+// Parámetro que acepta cualquier comando para ejecutarlo sin modificar los discos
+const dryRunParam = "dryrun"
+
+// Datos de la respuesta de un comando ejecutado con -dryrun
+// Changes son los cambios que habría hecho en cada disco y Result los datos que devolvería el comando
+type DryRunData struct {
+	Changes []DiskManagement.DryRunChange `json:"changes"`
+	Result  interface{}                   `json:"result,omitempty"`
+}
+
+// Funcion para saber si los parámetros de un comando incluyen -dryrun
+// Si los parámetros tienen errores de sintaxis los reporta el comando al separarlos
+func requestsDryRun(params string) bool {
+	tokens, err := lexParams(params, 0)
+	if err != nil {
+		return false
+	}
+	for _, param := range tokens {
+		if param.Name == dryRunParam {
+			return true
+		}
+	}
+	return false
+}
+
+// Funcion para ejecutar un comando sobre vistas copy-on-write de los discos
+// Si ya hay un dry-run activo (por ejemplo un comando de un script ejecutado con -dryrun) el comando se ejecuta en él
+// Los comandos que escriben en el sistema anfitrión no se ejecutan
+func runDryRun(definition Command, command string, params string) (CommandResult, error) {
+	if definition.WritesHost {
+		return CommandResult{Output: fmt.Sprintf("> Omitido con -dryrun: %s escribe archivos en el sistema anfitrión", definition.Name)}, nil
+	}
+	if !DiskManagement.BeginDryRun() {
+		return definition.Handler(command, params)
+	}
+
+	result, err := definition.Handler(command, params)
+	changes := DiskManagement.EndDryRun()
+	report := dryRunReport(changes)
+	result.Data = DryRunData{Changes: changes, Result: result.Data}
+	if err != nil {
+		return result, fmt.Errorf("%w\n%s", err, report)
+	}
+	if result.Output != "" {
+		report = result.Output + "\n" + report
+	}
+	result.Output = report
+	return result, nil
+}
+
+// Funcion para ejecutar los comandos de una solicitud, respond recibe la respuesta de cada uno
+// Con dryrun todos los comandos comparten el mismo dry-run y se devuelve la respuesta con los cambios,
+// mientras tanto no se ejecutan otros comandos ni operaciones de archivos para que no vean los discos sin escribir
+func runRequest(ctx context.Context, request CommandRequest, respond func(CommandResponse)) *CommandResponse {
+	if !request.DryRun {
+		for _, command := range request.lines() {
+			// Si el cliente cerró la conexión no se ejecuta el resto del script
+			if ctx.Err() != nil {
+				return nil
+			}
+			respond(runCommand(command))
+		}
+		return nil
+	}
+
+	filesMutex.Lock()
+	defer filesMutex.Unlock()
+
+	inicio := time.Now()
+	DiskManagement.BeginDryRun()
+	for _, command := range request.lines() {
+		if ctx.Err() != nil {
+			break
+		}
+		respond(executeLine(command))
+	}
+	changes := DiskManagement.EndDryRun()
+	return &CommandResponse{
+		Command:    dryRunParam,
+		Success:    true,
+		Message:    dryRunReport(changes),
+		DurationMs: float64(time.Since(inicio).Microseconds()) / 1000,
+		Data:       DryRunData{Changes: changes},
+	}
+}
+
+// Funcion para armar el reporte de un dry-run, una sección por disco con los cambios de su tabla de particiones
+func dryRunReport(changes []DiskManagement.DryRunChange) string {
+	var builder strings.Builder
+	if len(changes) == 0 {
+		builder.WriteString("> Dry-run: ningún disco habría cambiado")
+		return builder.String()
+	}
+
+	fmt.Fprintf(&builder, "> Dry-run: %d disco(s) habrían cambiado, no se escribió nada", len(changes))
+	for _, change := range changes {
+		estado := "modificado"
+		if change.Deleted {
+			estado = "eliminado"
+		} else if change.Created {
+			estado = "creado"
+		}
+		fmt.Fprintf(&builder, "\n  %s: %s, %d bytes escritos", change.Path, estado, change.BytesWritten)
+		for _, partition := range change.Partitions {
+			switch partition.Change {
+			case "added":
+				fmt.Fprintf(&builder, "\n    + %s", partitionSummary(*partition.After))
+			case "removed":
+				fmt.Fprintf(&builder, "\n    - %s", partitionSummary(*partition.Before))
+			default:
+				fmt.Fprintf(&builder, "\n    ~ %s -> %s", partitionSummary(*partition.Before), partitionSummary(*partition.After))
+			}
+		}
+	}
+	return builder.String()
+}
+
+// Funcion para describir una partición en una línea del reporte de dry-run
+func partitionSummary(partition DiskManagement.LayoutPartition) string {
+	summary := fmt.Sprintf("%s (tipo %s, inicio %d, %d bytes, fit %s", partition.Name, partition.Type, partition.Start, partition.Size, partition.Fit)
+	if partition.Id != "" {
+		summary += ", id " + partition.Id
+	}
+	if partition.Status != "" {
+		summary += ", estado " + partition.Status
+	}
+	return summary + ")"
+}
@@ -0,0 +1,239 @@
+package Analyzer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"proyecto1/Errors"
+	"proyecto1/FileSystem"
+	"proyecto1/Structs"
+	"strings"
+)
+
+// Entrada de una carpeta en la API de archivos
+type FileEntry struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Size        int64  `json:"size"`
+	Permissions string `json:"permissions"`
+	UID         int32  `json:"uid"`
+	GID         int32  `json:"gid"`
+	Modified    string `json:"modified"`
+}
+
+type DirectoryData struct {
+	Path    string      `json:"path"`
+	Entries []FileEntry `json:"entries"`
+}
+
+// FilesHandler permite navegar y editar una partición montada y formateada en /fs/{id}/{path...}
+// GET lista una carpeta o devuelve el contenido de un archivo, PUT crea o reemplaza un archivo con el cuerpo,
+// POST ?action=mkdir crea una carpeta (con &parents=true crea las intermedias) y DELETE elimina
+// (una carpeta con contenido requiere ?recursive=true)
+// Se autentica con Basic auth contra /users.txt y se validan los mismos permisos que en WebDAV
+func FilesHandler(w http.ResponseWriter, r *http.Request) {
+	id := strings.ToLower(r.PathValue("id"))
+	fsPath := path.Clean("/" + r.PathValue("path"))
+
+	user, err := authenticateRequest(r, id)
+	if err != nil {
+		if errors.Is(err, Errors.ErrDenied) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="MIA"`)
+			writeJSONError(w, http.StatusUnauthorized, err)
+			return
+		}
+		writeJSONError(w, errorStatus(err), err)
+		return
+	}
+	// El cuerpo se limita al tamaño máximo de un archivo, un PUT más grande no cabe en ningún inodo
+	r.Body = http.MaxBytesReader(w, r.Body, FileSystem.MaxFileSize)
+	fs := &partitionFS{id: id, user: user}
+
+	switch r.Method {
+	case http.MethodGet:
+		err = getFile(w, r, fs, fsPath)
+	case http.MethodPut:
+		err = putFile(w, r, fs, fsPath)
+	case http.MethodPost:
+		err = postFile(w, r, fs, fsPath)
+	case http.MethodDelete:
+		err = deleteFile(w, r, fs, fsPath)
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST, DELETE")
+		writeJSONError(w, http.StatusMethodNotAllowed, fmt.Errorf("Método %s no soportado", r.Method))
+		return
+	}
+	if err != nil {
+		status, err := filesError(fs, fsPath, err)
+		writeJSONError(w, status, err)
+	}
+}
+
+func getFile(w http.ResponseWriter, r *http.Request, fs *partitionFS, fsPath string) error {
+	file, err := fs.OpenFile(r.Context(), fsPath, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		content, err := io.ReadAll(file)
+		if err != nil {
+			return err
+		}
+		w.Header().Set("Content-Type", http.DetectContentType(content))
+		w.Write(content)
+		return nil
+	}
+
+	children, err := file.Readdir(0)
+	if err != nil {
+		return err
+	}
+	datos := DirectoryData{Path: fsPath, Entries: []FileEntry{}}
+	for _, child := range children {
+		datos.Entries = append(datos.Entries, fileEntry(child))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(datos)
+	return nil
+}
+
+func putFile(w http.ResponseWriter, r *http.Request, fs *partitionFS, fsPath string) error {
+	if fsPath == "/" {
+		return &Errors.DiskError{Kind: Errors.ErrInvalid, Message: "La raíz no puede reemplazarse por un archivo"}
+	}
+	_, statErr := fs.Stat(r.Context(), fsPath)
+	created := errors.Is(statErr, os.ErrNotExist)
+
+	file, err := fs.OpenFile(r.Context(), fsPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, r.Body); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	return writeFileEntry(w, r, fs, fsPath, status)
+}
+
+func postFile(w http.ResponseWriter, r *http.Request, fs *partitionFS, fsPath string) error {
+	if action := r.URL.Query().Get("action"); action != "mkdir" {
+		return &Errors.DiskError{Kind: Errors.ErrInvalid, Message: fmt.Sprintf("Acción '%s' no soportada, debe ser mkdir", action)}
+	}
+
+	if r.URL.Query().Get("parents") == "true" {
+		// Como mkdir -p, las carpetas existentes se conservan
+		current := "/"
+		for _, name := range strings.Split(fsPath, "/") {
+			if name == "" {
+				continue
+			}
+			current = path.Join(current, name)
+			if err := fs.Mkdir(r.Context(), current, 0); err != nil && !errors.Is(err, os.ErrExist) {
+				return err
+			}
+		}
+		info, err := fs.Stat(r.Context(), fsPath)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return &Errors.DiskError{Kind: Errors.ErrInvalid, Message: fmt.Sprintf("%s ya existe y no es una carpeta", fsPath)}
+		}
+	} else if err := fs.Mkdir(r.Context(), fsPath, 0); err != nil {
+		return err
+	}
+	return writeFileEntry(w, r, fs, fsPath, http.StatusCreated)
+}
+
+func deleteFile(w http.ResponseWriter, r *http.Request, fs *partitionFS, fsPath string) error {
+	if fsPath == "/" {
+		return &Errors.DiskError{Kind: Errors.ErrInvalid, Message: "La raíz no puede eliminarse"}
+	}
+	info, err := fs.Stat(r.Context(), fsPath)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() && r.URL.Query().Get("recursive") != "true" {
+		dir, err := fs.OpenFile(r.Context(), fsPath, os.O_RDONLY, 0)
+		if err != nil {
+			return err
+		}
+		children, err := dir.Readdir(0)
+		dir.Close()
+		if err != nil {
+			return err
+		}
+		if len(children) > 0 {
+			return &Errors.DiskError{Kind: Errors.ErrInvalid, Code: "not_empty", Message: fmt.Sprintf("La carpeta %s no está vacía, use ?recursive=true", fsPath)}
+		}
+	}
+	if err := fs.RemoveAll(r.Context(), fsPath); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// Funcion para responder la entrada de un archivo o carpeta recién creado o modificado
+func writeFileEntry(w http.ResponseWriter, r *http.Request, fs *partitionFS, fsPath string, status int) error {
+	info, err := fs.Stat(r.Context(), fsPath)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(fileEntry(info))
+	return nil
+}
+
+// Funcion para convertir la información de un inodo a una entrada de la API
+func fileEntry(info os.FileInfo) FileEntry {
+	entry := FileEntry{Name: info.Name(), Type: "file", Size: info.Size(), Modified: info.ModTime().Format("2006-01-02 15:04")}
+	if info.IsDir() {
+		entry.Type = "folder"
+	}
+	if inode, ok := info.Sys().(Structs.Inode); ok {
+		entry.Permissions = FileSystem.PermissionString(inode)
+		entry.UID = inode.I_uid
+		entry.GID = inode.I_gid
+	}
+	return entry
+}
+
+// Funcion para obtener el código HTTP y el mensaje de un error de la API de archivos
+// Las operaciones comparten el sistema de archivos de WebDAV, que devuelve errores de os
+func filesError(fs *partitionFS, fsPath string, err error) (int, error) {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		return http.StatusRequestEntityTooLarge, fmt.Errorf("El contenido excede el tamaño máximo de un archivo (%d bytes)", tooLarge.Limit)
+	case errors.Is(err, os.ErrPermission) && fs.denied != nil:
+		return http.StatusForbidden, fs.denied
+	case errors.Is(err, os.ErrPermission):
+		return http.StatusForbidden, fmt.Errorf("Operación no permitida sobre %s", fsPath)
+	case errors.Is(err, os.ErrNotExist):
+		return http.StatusNotFound, fmt.Errorf("La ruta %s no existe", fsPath)
+	case errors.Is(err, os.ErrExist):
+		return http.StatusConflict, fmt.Errorf("La ruta %s ya existe", fsPath)
+	default:
+		return errorStatus(err), err
+	}
+}
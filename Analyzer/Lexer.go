@@ -0,0 +1,193 @@
+package Analyzer
+
+import (
+	"flag"
+	"fmt"
+	"proyecto1/Errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Parámetro de un comando, -nombre=valor o -nombre sin valor
+// El nombre se guarda en minúsculas y el valor tal como se escribió, sin las comillas
+// Column es la columna (desde 1) del guion dentro de la línea del comando
+type Param struct {
+	Name     string
+	Value    string
+	HasValue bool
+	Column   int
+}
+
+// Error de sintaxis o de validación de los parámetros de un comando
+// Code es el código estable del error que se incluye en la respuesta
+type ParamError struct {
+	Column  int
+	Code    string
+	Message string
+}
+
+func (e *ParamError) Error() string {
+	return fmt.Sprintf("columna %d: %s", e.Column, e.Message)
+}
+
+// Los errores de parámetros son errores de validación
+func (e *ParamError) Unwrap() error {
+	return Errors.ErrInvalid
+}
+
+// Funcion para separar los parámetros de un comando
+// Los valores pueden ir entre comillas dobles para incluir espacios o '=', dentro de ellas \" es una comilla y \\ una barra
+// base es la cantidad de columnas que hay antes de params en la línea del comando
+func lexParams(params string, base int) ([]Param, error) {
+	input := []rune(params)
+	var result []Param
+
+	i := 0
+	for i < len(input) {
+		if unicode.IsSpace(input[i]) {
+			i++
+			continue
+		}
+
+		column := base + i + 1
+		if input[i] != '-' {
+			start := i
+			for i < len(input) && !unicode.IsSpace(input[i]) {
+				i++
+			}
+			return nil, &ParamError{Column: column, Code: "syntax_error", Message: fmt.Sprintf("se esperaba un parámetro (-nombre=valor) y se encontró '%s'", string(input[start:i]))}
+		}
+
+		i++
+		start := i
+		for i < len(input) && (input[i] == '_' || unicode.IsLetter(input[i]) || unicode.IsDigit(input[i])) {
+			i++
+		}
+		if i == start {
+			return nil, &ParamError{Column: column, Code: "syntax_error", Message: "falta el nombre del parámetro después de '-'"}
+		}
+		param := Param{Name: strings.ToLower(string(input[start:i])), Column: column}
+
+		if i < len(input) && input[i] == '=' {
+			i++
+			param.HasValue = true
+			if i < len(input) && input[i] == '"' {
+				quote := base + i + 1
+				i++
+				var value []rune
+				closed := false
+				for i < len(input) {
+					if input[i] == '\\' && i+1 < len(input) && (input[i+1] == '"' || input[i+1] == '\\') {
+						value = append(value, input[i+1])
+						i += 2
+						continue
+					}
+					if input[i] == '"' {
+						closed = true
+						i++
+						break
+					}
+					value = append(value, input[i])
+					i++
+				}
+				if !closed {
+					return nil, &ParamError{Column: quote, Code: "syntax_error", Message: fmt.Sprintf("comillas sin cerrar en el valor de -%s", param.Name)}
+				}
+				if i < len(input) && !unicode.IsSpace(input[i]) {
+					return nil, &ParamError{Column: base + i + 1, Code: "syntax_error", Message: fmt.Sprintf("se esperaba un espacio después del valor de -%s", param.Name)}
+				}
+				param.Value = string(value)
+			} else {
+				valueStart := i
+				for i < len(input) && !unicode.IsSpace(input[i]) {
+					i++
+				}
+				if i == valueStart {
+					return nil, &ParamError{Column: column, Code: "syntax_error", Message: fmt.Sprintf("el parámetro -%s no tiene valor", param.Name)}
+				}
+				param.Value = string(input[valueStart:i])
+			}
+		} else if i < len(input) && !unicode.IsSpace(input[i]) {
+			return nil, &ParamError{Column: base + i + 1, Code: "syntax_error", Message: fmt.Sprintf("carácter '%c' inesperado en el nombre del parámetro -%s", input[i], param.Name)}
+		}
+		result = append(result, param)
+	}
+	return result, nil
+}
+
+// Funcion para obtener los valores de los parámetros como se indicaron, los que van sin valor son "true"
+// Si los parámetros tienen errores de sintaxis no se devuelve ninguno, el error lo reporta el comando
+func paramValues(params string) map[string]string {
+	tokens, err := lexParams(params, 0)
+	if err != nil {
+		return nil
+	}
+	values := make(map[string]string)
+	for _, param := range tokens {
+		values[param.Name] = param.Value
+		if !param.HasValue {
+			values[param.Name] = "true"
+		}
+	}
+	return values
+}
+
+// Funcion para asignar los parámetros de un comando a su FlagSet, que define los parámetros que acepta
+// Los obligatorios se toman de la declaración del comando con el mismo nombre que el FlagSet
+// Los parámetros desconocidos, repetidos, con valores inválidos o los obligatorios que faltan son un error
+// Solo los parámetros booleanos (por ejemplo -prealloc) pueden ir sin valor
+func parseParams(fs *flag.FlagSet, params string) error {
+	var required []string
+	if command, ok := lookupCommand(fs.Name()); ok {
+		required = requiredParams(command)
+	}
+
+	base := utf8.RuneCountInString(fs.Name())
+	tokens, err := lexParams(params, base)
+	if err != nil {
+		return fmt.Errorf("Error: %w", err)
+	}
+
+	seen := make(map[string]Param)
+	for _, param := range tokens {
+		definition := fs.Lookup(param.Name)
+		if definition == nil && param.Name != dryRunParam {
+			return fmt.Errorf("Error: %w", &ParamError{Column: param.Column, Code: "unknown_param", Message: fmt.Sprintf("parámetro -%s desconocido para %s", param.Name, fs.Name())})
+		}
+		if previous, ok := seen[param.Name]; ok {
+			return fmt.Errorf("Error: %w", &ParamError{Column: param.Column, Code: "duplicate_param", Message: fmt.Sprintf("parámetro -%s repetido, ya se indicó en la columna %d", param.Name, previous.Column)})
+		}
+		seen[param.Name] = param
+
+		// -dryrun lo acepta cualquier comando, lo atiende AnalyzeCommnad antes de ejecutarlo
+		if param.Name == dryRunParam {
+			if param.HasValue {
+				return fmt.Errorf("Error: %w", &ParamError{Column: param.Column, Code: "invalid_value", Message: fmt.Sprintf("el parámetro -%s va sin valor", dryRunParam)})
+			}
+			continue
+		}
+
+		value := param.Value
+		if !param.HasValue {
+			if boolFlag, ok := definition.Value.(interface{ IsBoolFlag() bool }); !ok || !boolFlag.IsBoolFlag() {
+				return fmt.Errorf("Error: %w", &ParamError{Column: param.Column, Code: "missing_value", Message: fmt.Sprintf("el parámetro -%s necesita un valor (-%s=valor)", param.Name, param.Name)})
+			}
+			value = "true"
+		}
+		if err := fs.Set(param.Name, value); err != nil {
+			return fmt.Errorf("Error: %w", &ParamError{Column: param.Column, Code: "invalid_value", Message: fmt.Sprintf("valor '%s' inválido para -%s", value, param.Name)})
+		}
+	}
+
+	for _, name := range required {
+		param, ok := seen[name]
+		if !ok {
+			return fmt.Errorf("Error: %w", &ParamError{Column: base + utf8.RuneCountInString(params) + 1, Code: "missing_param", Message: fmt.Sprintf("falta el parámetro obligatorio -%s", name)})
+		}
+		if param.Value == "" {
+			return fmt.Errorf("Error: %w", &ParamError{Column: param.Column, Code: "empty_value", Message: fmt.Sprintf("el parámetro -%s no puede estar vacío", name)})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package Analyzer
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLexParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		params string
+		want   []Param
+		column int
+	}{
+		{
+			name:   "valores simples",
+			params: " -size=5 -PATH=/tmp/a.mia",
+			want:   []Param{{Name: "size", Value: "5", HasValue: true, Column: 2}, {Name: "path", Value: "/tmp/a.mia", HasValue: true, Column: 10}},
+		},
+		{
+			name:   "comillas con espacios e igual",
+			params: ` -path="/tmp/mis discos/a=b.mia"`,
+			want:   []Param{{Name: "path", Value: "/tmp/mis discos/a=b.mia", HasValue: true, Column: 2}},
+		},
+		{
+			name:   "comillas y barras escapadas",
+			params: ` -cont="dijo \"hola\" c:\\x"`,
+			want:   []Param{{Name: "cont", Value: `dijo "hola" c:\x`, HasValue: true, Column: 2}},
+		},
+		{
+			name:   "valor vacío entre comillas",
+			params: ` -name=""`,
+			want:   []Param{{Name: "name", Value: "", HasValue: true, Column: 2}},
+		},
+		{
+			name:   "parámetro sin valor",
+			params: "  -prealloc -size=1",
+			want:   []Param{{Name: "prealloc", Column: 3}, {Name: "size", Value: "1", HasValue: true, Column: 13}},
+		},
+		{name: "comillas sin cerrar", params: ` -path="/tmp/a`, column: 8},
+		{name: "texto después de las comillas", params: ` -path="a"b`, column: 11},
+		{name: "sin guion", params: " size=5", column: 2},
+		{name: "guion sin nombre", params: " - -size=5", column: 2},
+		{name: "igual sin valor", params: " -size= -path=a", column: 2},
+		{name: "carácter inválido en el nombre", params: " -si.ze=5", column: 5},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := lexParams(test.params, 0)
+			if test.column != 0 {
+				var paramErr *ParamError
+				if !errors.As(err, &paramErr) {
+					t.Fatalf("se esperaba un ParamError y se obtuvo %v", err)
+				}
+				if paramErr.Column != test.column || paramErr.Code != "syntax_error" {
+					t.Fatalf("error en la columna %d con código %s, se esperaba la columna %d", paramErr.Column, paramErr.Code, test.column)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("se obtuvo %+v, se esperaba %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseParamsErrors(t *testing.T) {
+	// Las columnas cuentan desde el inicio de la línea "mkdisk ..."
+	tests := []struct {
+		name   string
+		params string
+		code   string
+		column int
+	}{
+		{name: "desconocido", params: " -size=5 -path=a -color=rojo", code: "unknown_param", column: 24},
+		{name: "repetido", params: " -size=5 -path=a -SIZE=6", code: "duplicate_param", column: 24},
+		{name: "falta obligatorio", params: " -size=5", code: "missing_param", column: 15},
+		{name: "obligatorio vacío", params: ` -size=5 -path=""`, code: "empty_value", column: 16},
+		{name: "sin valor", params: " -size -path=a", code: "missing_value", column: 8},
+		{name: "valor inválido", params: " -size=cinco -path=a", code: "invalid_value", column: 8},
+		{name: "dryrun con valor", params: " -size=5 -path=a -dryrun=true", code: "invalid_value", column: 24},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := parseParams(flagsFor("mkdisk").FlagSet, test.params)
+			var paramErr *ParamError
+			if !errors.As(err, &paramErr) {
+				t.Fatalf("se esperaba un ParamError y se obtuvo %v", err)
+			}
+			if paramErr.Code != test.code || paramErr.Column != test.column {
+				t.Fatalf("se obtuvo %s en la columna %d, se esperaba %s en la columna %d", paramErr.Code, paramErr.Column, test.code, test.column)
+			}
+		})
+	}
+
+	// Con un alias las columnas se cuentan desde el nombre que se escribió
+	var paramErr *ParamError
+	if err := parseParams(flagsFor("exec").FlagSet, " -path=a.smia -x=1"); !errors.As(err, &paramErr) || paramErr.Column != 19 {
+		t.Fatalf("se esperaba un error en la columna 19 y se obtuvo %v", err)
+	}
+
+	fs := flagsFor("mkdisk").FlagSet
+	if err := parseParams(fs, ` -size=5 -prealloc -path="/tmp/a b.mia" -dryrun`); err != nil {
+		t.Fatal(err)
+	}
+	if fs.Lookup("prealloc").Value.String() != "true" || fs.Lookup("path").Value.String() != "/tmp/a b.mia" {
+		t.Fatal("los valores no se asignaron al FlagSet")
+	}
+}
+
+func TestHandlersUseDeclaredParams(t *testing.T) {
+	// Un handler que pide un parámetro que no está en la declaración falla antes de analizar la línea
+	for _, command := range commands {
+		t.Run(command.Name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatal(r)
+				}
+			}()
+			command.Handler(command.Name, "")
+		})
+	}
+}
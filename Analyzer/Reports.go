@@ -0,0 +1,533 @@
+package Analyzer
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"proyecto1/DiskManagement"
+	"proyecto1/Errors"
+	"proyecto1/FileSystem"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+	"strings"
+)
+
+// Nombres de los reportes disponibles para rep y para la API de reportes
+var reportNames = []string{"mbr", "disk", "sb", "file", "ls", "journaling"}
+
+// Estructuras con los datos de cada reporte, se devuelven como JSON en la API de reportes
+type PartitionData struct {
+	Status      string `json:"status"`
+	Type        string `json:"type"`
+	Fit         string `json:"fit"`
+	Start       int32  `json:"start"`
+	Size        int32  `json:"size"`
+	Name        string `json:"name"`
+	Correlative int32  `json:"correlative"`
+	Id          string `json:"id"`
+}
+
+type EBRData struct {
+	Mount string `json:"mount"`
+	Fit   string `json:"fit"`
+	Start int32  `json:"start"`
+	Size  int32  `json:"size"`
+	Next  int32  `json:"next"`
+	Name  string `json:"name"`
+}
+
+type MBRData struct {
+	Size         int32           `json:"size"`
+	CreationDate string          `json:"creation_date"`
+	Signature    int32           `json:"signature"`
+	Fit          string          `json:"fit"`
+	Checksum     uint32          `json:"checksum"`
+	Partitions   []PartitionData `json:"partitions"`
+	EBRs         []EBRData       `json:"ebrs"`
+}
+
+type DiskSegment struct {
+	Type       string        `json:"type"`
+	Name       string        `json:"name,omitempty"`
+	Start      int32         `json:"start"`
+	Size       int32         `json:"size"`
+	Percentage float64       `json:"percentage"`
+	Children   []DiskSegment `json:"children,omitempty"`
+}
+
+type DiskData struct {
+	File     string        `json:"file"`
+	Size     int32         `json:"size"`
+	Segments []DiskSegment `json:"segments"`
+}
+
+type SuperblockData struct {
+	FilesystemType  int32  `json:"filesystem_type"`
+	InodesCount     int32  `json:"inodes_count"`
+	BlocksCount     int32  `json:"blocks_count"`
+	FreeBlocksCount int32  `json:"free_blocks_count"`
+	FreeInodesCount int32  `json:"free_inodes_count"`
+	Mtime           string `json:"mtime"`
+	Umtime          string `json:"umtime"`
+	MntCount        int32  `json:"mnt_count"`
+	Magic           int32  `json:"magic"`
+	InodeSize       int32  `json:"inode_size"`
+	BlockSize       int32  `json:"block_size"`
+	FirstIno        int32  `json:"first_ino"`
+	FirstBlo        int32  `json:"first_blo"`
+	BmInodeStart    int32  `json:"bm_inode_start"`
+	BmBlockStart    int32  `json:"bm_block_start"`
+	InodeStart      int32  `json:"inode_start"`
+	BlockStart      int32  `json:"block_start"`
+	Checksum        uint32 `json:"checksum"`
+}
+
+type FileData struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+type JournalData struct {
+	Count     int32  `json:"count"`
+	Operation string `json:"operation"`
+	Path      string `json:"path"`
+	Content   string `json:"content"`
+	Date      string `json:"date"`
+}
+
+// ReportDataHandler devuelve en JSON los datos de un reporte de una partición montada
+// GET /reports/{id}/{name}, los reportes file y ls reciben la ruta en ?path_file_ls=
+func ReportDataHandler(w http.ResponseWriter, r *http.Request) {
+	id, name := r.PathValue("id"), r.PathValue("name")
+	status, datos, _, err := lockedReport(id, name, r.URL.Query().Get("path_file_ls"))
+	if err != nil {
+		writeJSONError(w, status, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(datos)
+}
+
+// ReportImageHandler devuelve la imagen renderizada de un reporte de una partición montada
+// GET /reports/{id}/{name}/image, el formato se indica con ?format=svg|png|jpg|pdf (svg por defecto)
+func ReportImageHandler(w http.ResponseWriter, r *http.Request) {
+	id, name := r.PathValue("id"), r.PathValue("name")
+	if name == "file" {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("El reporte file no tiene imagen, use /reports/%s/file", id))
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "svg"
+	}
+	if _, err := Utilities.ReportFormat("reporte." + format); err != nil || format == "txt" {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("Formato %s no soportado, debe ser svg, png, jpg o pdf", format))
+		return
+	}
+
+	status, _, reporte, err := lockedReport(id, name, r.URL.Query().Get("path_file_ls"))
+	if err != nil {
+		writeJSONError(w, status, err)
+		return
+	}
+
+	// El reporte se genera en una carpeta temporal y se envía al cliente
+	dir, err := os.MkdirTemp("", "reporte")
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	outputPath := filepath.Join(dir, name+"."+format)
+	if err := Utilities.RenderReport(reporte, outputPath); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	http.ServeFile(w, r, outputPath)
+}
+
+// Funcion para validar y cargar un reporte mientras no se ejecuta ningún comando
+// Así no se leen las particiones montadas mientras cambian ni los discos de un dry-run de otra solicitud
+func lockedReport(id string, name string, pathFileLs string) (int, interface{}, Utilities.Report, error) {
+	filesMutex.Lock()
+	defer filesMutex.Unlock()
+	if status, err := validateReportRequest(id, name); err != nil {
+		return status, nil, Utilities.Report{}, err
+	}
+	datos, reporte, err := loadReport(id, name, pathFileLs)
+	if err != nil {
+		return errorStatus(err), nil, Utilities.Report{}, err
+	}
+	return http.StatusOK, datos, reporte, nil
+}
+
+// Funcion para validar el nombre del reporte y que la partición esté montada
+func validateReportRequest(id string, name string) (int, error) {
+	found := false
+	for _, reportName := range reportNames {
+		if reportName == name {
+			found = true
+		}
+	}
+	if !found {
+		return http.StatusNotFound, fmt.Errorf("Reporte %s no encontrado", name)
+	}
+	if _, _, err := DiskManagement.GetPartitionByID(id); err != nil {
+		return errorStatus(err), err
+	}
+	return http.StatusOK, nil
+}
+
+// Funcion para obtener el código HTTP que corresponde al tipo de error de una operación de disco
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, Errors.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, Errors.ErrCorrupt):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, Errors.ErrDenied):
+		return http.StatusForbidden
+	case errors.Is(err, Errors.ErrIO):
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// Funcion para responder un error en formato JSON
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// Funcion para limpiar los caracteres nulos de los arreglos de bytes de las estructuras
+func cleanBytes(data []byte) string {
+	return strings.TrimRight(string(data), "\x00")
+}
+
+// Funcion para obtener los datos y el reporte con el nombre dado de una partición montada
+// El primer valor son los datos estructurados del reporte y el segundo su representación tabular
+func loadReport(id string, name string, pathFileLs string) (interface{}, Utilities.Report, error) {
+	switch name {
+	case "mbr":
+		return loadReportMBR(id)
+	case "disk":
+		return loadReportDisk(id)
+	case "sb":
+		return loadReportSB(id)
+	case "file":
+		return loadReportFile(id, pathFileLs)
+	case "ls":
+		return loadReportLs(id, pathFileLs)
+	case "journaling":
+		return loadReportJournaling(id)
+	default:
+		return nil, Utilities.Report{}, fmt.Errorf("Reporte %s no encontrado", name)
+	}
+}
+
+func loadReportMBR(id string) (interface{}, Utilities.Report, error) {
+	_, pathDisco, err := DiskManagement.GetPartitionByID(id)
+	if err != nil {
+		return nil, Utilities.Report{}, err
+	}
+
+	TempMBR, ebrs, err := DiskManagement.ReadDisk(pathDisco)
+	if err != nil {
+		return nil, Utilities.Report{}, err
+	}
+
+	data := MBRData{
+		Size:         TempMBR.MbrSize,
+		CreationDate: cleanBytes(TempMBR.CreationDate[:]),
+		Signature:    TempMBR.Signature,
+		Checksum:     TempMBR.Checksum,
+		Fit:          cleanBytes(TempMBR.Fit[:]),
+		Partitions:   []PartitionData{},
+		EBRs:         []EBRData{},
+	}
+	for _, part := range TempMBR.Partitions {
+		if part.Size <= 0 {
+			continue
+		}
+		data.Partitions = append(data.Partitions, PartitionData{
+			Status:      cleanBytes(part.Status[:]),
+			Type:        cleanBytes(part.Type[:]),
+			Fit:         cleanBytes(part.Fit[:]),
+			Start:       part.Start,
+			Size:        part.Size,
+			Name:        cleanBytes(part.Name[:]),
+			Correlative: part.Correlative,
+			Id:          cleanBytes(part.Id[:]),
+		})
+	}
+	for _, ebr := range ebrs {
+		data.EBRs = append(data.EBRs, EBRData{
+			Mount: cleanBytes([]byte{ebr.PartMount}),
+			Fit:   cleanBytes([]byte{ebr.PartFit}),
+			Start: ebr.PartStart,
+			Size:  ebr.PartSize,
+			Next:  ebr.PartNext,
+			Name:  cleanBytes(ebr.PartName[:]),
+		})
+	}
+
+	return data, Utilities.BuildReportMBR(TempMBR, ebrs), nil
+}
+
+func loadReportDisk(id string) (interface{}, Utilities.Report, error) {
+	_, pathDisco, err := DiskManagement.GetPartitionByID(id)
+	if err != nil {
+		return nil, Utilities.Report{}, err
+	}
+
+	TempMBR, ebrs, err := DiskManagement.ReadDisk(pathDisco)
+	if err != nil {
+		return nil, Utilities.Report{}, err
+	}
+
+	totalDiskSize := TempMBR.MbrSize
+	fileName := filepath.Base(pathDisco)
+	percentage := func(size int32) float64 {
+		return float64(size) / float64(totalDiskSize) * 100
+	}
+
+	// Los segmentos siguen el mismo cálculo del reporte disk
+	data := DiskData{File: fileName, Size: totalDiskSize}
+	usedSpace := int32(binary.Size(TempMBR)) // Tamaño del MBR en bytes
+	data.Segments = append(data.Segments, DiskSegment{Type: "mbr", Start: 0, Size: usedSpace, Percentage: percentage(usedSpace)})
+	for _, part := range TempMBR.Partitions {
+		if part.Size <= 0 {
+			continue
+		}
+		segment := DiskSegment{Name: cleanBytes(part.Name[:]), Start: part.Start, Size: part.Size, Percentage: percentage(part.Size)}
+		if string(part.Type[:]) == "p" {
+			segment.Type = "primaria"
+		} else if string(part.Type[:]) == "e" {
+			segment.Type = "extendida"
+			var usedExtended int32
+			ebrSize := int32(binary.Size(Structs.EBR{}))
+			ebrPosition := part.Start
+			for _, ebr := range ebrs {
+				segment.Children = append(segment.Children,
+					DiskSegment{Type: "ebr", Start: ebrPosition, Size: ebrSize, Percentage: percentage(ebrSize)},
+					DiskSegment{Type: "logica", Name: cleanBytes(ebr.PartName[:]), Start: ebr.PartStart, Size: ebr.PartSize, Percentage: percentage(ebr.PartSize)},
+				)
+				usedExtended += ebr.PartSize + ebrSize
+				ebrPosition = ebr.PartNext
+			}
+			freeExtended := part.Size - usedExtended
+			segment.Children = append(segment.Children, DiskSegment{Type: "libre", Start: part.Start + usedExtended, Size: freeExtended, Percentage: percentage(freeExtended)})
+		} else {
+			continue
+		}
+		data.Segments = append(data.Segments, segment)
+		usedSpace += part.Size
+	}
+	freeSpace := totalDiskSize - usedSpace
+	data.Segments = append(data.Segments, DiskSegment{Type: "libre", Start: usedSpace, Size: freeSpace, Percentage: percentage(freeSpace)})
+
+	return data, Utilities.BuildReportDisk(TempMBR, ebrs, totalDiskSize, fileName), nil
+}
+
+func loadReportSB(id string) (interface{}, Utilities.Report, error) {
+	particion, pathDisco, err := DiskManagement.GetPartitionByID(id)
+	if err != nil {
+		return nil, Utilities.Report{}, err
+	}
+
+	file, err := DiskManagement.OpenDisk(pathDisco)
+	if err != nil {
+		return nil, Utilities.Report{}, err
+	}
+	defer file.Close()
+
+	//El superbloque se encuentra al inicio de la partición
+	TempSB, err := FileSystem.ReadSuperblock(file, particion)
+	if err != nil {
+		return nil, Utilities.Report{}, err
+	}
+
+	data := SuperblockData{
+		FilesystemType:  TempSB.S_filesystem_type,
+		InodesCount:     TempSB.S_inodes_count,
+		BlocksCount:     TempSB.S_blocks_count,
+		FreeBlocksCount: TempSB.S_free_blocks_count,
+		FreeInodesCount: TempSB.S_free_inodes_count,
+		Mtime:           cleanBytes(TempSB.S_mtime[:]),
+		Umtime:          cleanBytes(TempSB.S_umtime[:]),
+		MntCount:        TempSB.S_mnt_count,
+		Magic:           TempSB.S_magic,
+		InodeSize:       TempSB.S_inode_size,
+		BlockSize:       TempSB.S_block_size,
+		FirstIno:        TempSB.S_fist_ino,
+		FirstBlo:        TempSB.S_first_blo,
+		BmInodeStart:    TempSB.S_bm_inode_start,
+		BmBlockStart:    TempSB.S_bm_block_start,
+		InodeStart:      TempSB.S_inode_start,
+		BlockStart:      TempSB.S_block_start,
+		Checksum:        TempSB.S_checksum,
+	}
+	return data, Utilities.BuildReportSB(TempSB), nil
+}
+
+func loadReportFile(id string, pathFileLs string) (interface{}, Utilities.Report, error) {
+	if pathFileLs == "" {
+		return nil, Utilities.Report{}, fmt.Errorf("path_file_ls es obligatorio para el reporte file")
+	}
+
+	particion, pathDisco, err := DiskManagement.GetPartitionByID(id)
+	if err != nil {
+		return nil, Utilities.Report{}, err
+	}
+
+	file, err := DiskManagement.OpenDisk(pathDisco)
+	if err != nil {
+		return nil, Utilities.Report{}, err
+	}
+	defer file.Close()
+
+	TempSB, err := FileSystem.ReadSuperblock(file, particion)
+	if err != nil {
+		return nil, Utilities.Report{}, err
+	}
+
+	indexInode, err := FileSystem.SearchInode(file, TempSB, pathFileLs)
+	if err != nil {
+		return nil, Utilities.Report{}, err
+	}
+
+	inode, err := FileSystem.ReadInode(file, TempSB, indexInode)
+	if err != nil {
+		return nil, Utilities.Report{}, err
+	}
+
+	contenido, err := FileSystem.ReadFileContent(file, TempSB, inode)
+	if err != nil {
+		return nil, Utilities.Report{}, err
+	}
+
+	reporte := Utilities.Report{Title: pathFileLs}
+	reporte.Row(contenido)
+	return FileData{Path: pathFileLs, Content: contenido}, reporte, nil
+}
+
+func loadReportLs(id string, pathFileLs string) (interface{}, Utilities.Report, error) {
+	if pathFileLs == "" {
+		return nil, Utilities.Report{}, fmt.Errorf("path_file_ls es obligatorio para el reporte ls")
+	}
+
+	particion, pathDisco, err := DiskManagement.GetPartitionByID(id)
+	if err != nil {
+		return nil, Utilities.Report{}, err
+	}
+
+	file, err := DiskManagement.OpenDisk(pathDisco)
+	if err != nil {
+		return nil, Utilities.Report{}, err
+	}
+	defer file.Close()
+
+	TempSB, err := FileSystem.ReadSuperblock(file, particion)
+	if err != nil {
+		return nil, Utilities.Report{}, err
+	}
+
+	indexInode, err := FileSystem.SearchInode(file, TempSB, pathFileLs)
+	if err != nil {
+		return nil, Utilities.Report{}, err
+	}
+
+	carpeta, err := FileSystem.ReadInode(file, TempSB, indexInode)
+	if err != nil {
+		return nil, Utilities.Report{}, err
+	}
+
+	contenidos, err := FileSystem.ReadDirectory(file, TempSB, carpeta)
+	if err != nil {
+		return nil, Utilities.Report{}, err
+	}
+
+	//Los nombres de usuarios y grupos se obtienen de users.txt
+	usuarios, grupos, err := FileSystem.ReadUsersAndGroups(file, TempSB)
+	if err != nil {
+		log.Println("No se pudo leer users.txt:", err)
+	}
+
+	entries := []Utilities.LsEntry{}
+	for _, contenido := range contenidos {
+		inode, err := FileSystem.ReadInode(file, TempSB, contenido.B_inodo)
+		if err != nil {
+			return nil, Utilities.Report{}, err
+		}
+
+		owner, ok := usuarios[inode.I_uid]
+		if !ok {
+			owner = fmt.Sprintf("%d", inode.I_uid)
+		}
+		group, ok := grupos[inode.I_gid]
+		if !ok {
+			group = fmt.Sprintf("%d", inode.I_gid)
+		}
+		tipo := "Archivo"
+		if inode.I_type[0] == '0' {
+			tipo = "Carpeta"
+		}
+
+		entries = append(entries, Utilities.LsEntry{
+			Permissions: FileSystem.PermissionString(inode),
+			Owner:       owner,
+			Group:       group,
+			Size:        inode.I_size,
+			Date:        cleanBytes(inode.I_mtime[:]),
+			Type:        tipo,
+			Name:        FileSystem.ContentName(contenido),
+		})
+	}
+
+	return entries, Utilities.BuildReportLs(entries, pathFileLs), nil
+}
+
+func loadReportJournaling(id string) (interface{}, Utilities.Report, error) {
+	particion, pathDisco, err := DiskManagement.GetPartitionByID(id)
+	if err != nil {
+		return nil, Utilities.Report{}, err
+	}
+
+	file, err := DiskManagement.OpenDisk(pathDisco)
+	if err != nil {
+		return nil, Utilities.Report{}, err
+	}
+	defer file.Close()
+
+	TempSB, err := FileSystem.ReadSuperblock(file, particion)
+	if err != nil {
+		return nil, Utilities.Report{}, err
+	}
+
+	entradas, err := FileSystem.ReadJournal(file, particion, TempSB)
+	if err != nil {
+		return nil, Utilities.Report{}, err
+	}
+
+	data := []JournalData{}
+	for _, entrada := range entradas {
+		data = append(data, JournalData{
+			Count:     entrada.J_count,
+			Operation: cleanBytes(entrada.J_content.I_operation[:]),
+			Path:      cleanBytes(entrada.J_content.I_path[:]),
+			Content:   cleanBytes(entrada.J_content.I_content[:]),
+			Date:      cleanBytes(entrada.J_content.I_date[:]),
+		})
+	}
+	return data, Utilities.BuildReportJournaling(entradas), nil
+}
@@ -0,0 +1,97 @@
+package Analyzer
+
+import (
+	"errors"
+	"proyecto1/DiskManagement"
+	"proyecto1/Errors"
+	"proyecto1/FileSystem"
+	"sort"
+)
+
+// Partición montada, data de la respuesta de mount
+type MountData struct {
+	Path    string `json:"path"`
+	Name    string `json:"name"`
+	Id      string `json:"id"`
+	Mounted bool   `json:"mounted"`
+}
+
+// Resultado de fsck, data de la respuesta de fsck
+type FsckData struct {
+	Disk   string             `json:"disk"`
+	Issues []Errors.FsckIssue `json:"issues"`
+}
+
+// Comando deshecho por undo, con la cantidad de escrituras y bytes que se revirtieron
+type UndoData struct {
+	Command string `json:"command"`
+	Writes  int    `json:"writes"`
+	Bytes   int    `json:"bytes"`
+}
+
+// Funcion para obtener el código estable de error de un comando fallido
+// Los errores de parámetros y los de disco con un código específico usan ese código, los demás el tipo de error
+func errorCode(commandName string, err error) string {
+	var paramErr *ParamError
+	if errors.As(err, &paramErr) {
+		return paramErr.Code
+	}
+	if _, ok := lookupCommand(commandName); !ok {
+		return "unknown_command"
+	}
+	var diskErr *Errors.DiskError
+	if errors.As(err, &diskErr) && diskErr.Code != "" {
+		return diskErr.Code
+	}
+	if errors.Is(err, FileSystem.ErrNotFormatted) {
+		return "not_formatted"
+	}
+	return errorKind(err)
+}
+
+// Funcion para obtener las particiones montadas ordenadas por ID
+func mountedPartitionsData() []MountData {
+	datos := []MountData{}
+	for _, particiones := range DiskManagement.GetMountedPartitions() {
+		for _, particion := range particiones {
+			datos = append(datos, MountData{Path: particion.Path, Name: particion.Name, Id: particion.ID, Mounted: particion.Status == '1'})
+		}
+	}
+	sort.Slice(datos, func(i, j int) bool { return datos[i].Id < datos[j].Id })
+	return datos
+}
+
+// Funcion para obtener la geometría de una partición de un disco por su nombre, primaria, extendida o lógica
+func partitionGeometry(path string, name string) (PartitionData, error) {
+	TempMBR, ebrs, err := DiskManagement.ReadDisk(path)
+	if err != nil {
+		return PartitionData{}, err
+	}
+	for _, particion := range TempMBR.Partitions {
+		if particion.Size > 0 && cleanBytes(particion.Name[:]) == name {
+			return PartitionData{
+				Status:      cleanBytes(particion.Status[:]),
+				Type:        cleanBytes(particion.Type[:]),
+				Fit:         cleanBytes(particion.Fit[:]),
+				Start:       particion.Start,
+				Size:        particion.Size,
+				Name:        name,
+				Correlative: particion.Correlative,
+				Id:          cleanBytes(particion.Id[:]),
+			}, nil
+		}
+	}
+	for _, ebr := range ebrs {
+		if ebr.PartSize > 0 && cleanBytes(ebr.PartName[:]) == name {
+			return PartitionData{
+				Status: cleanBytes([]byte{ebr.PartMount}),
+				Type:   "l",
+				Fit:    cleanBytes([]byte{ebr.PartFit}),
+				Start:  ebr.PartStart,
+				Size:   ebr.PartSize,
+				Name:   name,
+			}, nil
+		}
+	}
+	return PartitionData{}, &Errors.DiskError{Kind: Errors.ErrNotFound, Code: "partition_not_found", Message: "No se encontró la partición " + name}
+}
@@ -0,0 +1,27 @@
+package Analyzer
+
+import (
+	"fmt"
+	"proyecto1/Errors"
+	"proyecto1/FileSystem"
+	"testing"
+)
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		command string
+		err     error
+		code    string
+	}{
+		{"mount", fmt.Errorf("Error: %w", &Errors.DiskError{Kind: Errors.ErrNotFound, Code: "partition_not_found"}), "partition_not_found"},
+		{"mount", fmt.Errorf("Error: %w", &Errors.DiskError{Kind: Errors.ErrNotFound}), "not_found"},
+		{"fsck", fmt.Errorf("Error: %w", FileSystem.ErrNotFormatted), "not_formatted"},
+		{"mkdisk", fmt.Errorf("Error: %w", &ParamError{Code: "missing_param"}), "missing_param"},
+		{"mkdsk", fmt.Errorf("Error: Comando mkdsk inválido"), "unknown_command"},
+	}
+	for _, test := range tests {
+		if code := errorCode(test.command, test.err); code != test.code {
+			t.Errorf("%s: se obtuvo %s, se esperaba %s", test.err, code, test.code)
+		}
+	}
+}
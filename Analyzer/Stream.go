@@ -0,0 +1,74 @@
+package Analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Resumen que se envía al terminar un script en StreamHandler
+type ScriptSummary struct {
+	Total      int     `json:"total"`
+	Failed     int     `json:"failed"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// StreamHandler ejecuta un script completo y envía por Server-Sent Events un evento "command" con la
+// respuesta de cada comando en cuanto termina, y un evento "done" con el resumen al final
+// Con dryrun antes del resumen se envía un evento "dryrun" con los cambios que habrían hecho los comandos
+// POST /analyze/stream con el mismo JSON que /analyze ({"commands": [...]} o {"script": "..."})
+func StreamHandler(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "El servidor no soporta streaming", http.StatusInternalServerError)
+		return
+	}
+
+	request, err := decodeCommandRequest(r)
+	if err != nil {
+		http.Error(w, "Error decodificando JSON", http.StatusBadRequest)
+		log.Println("Error decodificando JSON:", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	inicio := time.Now()
+	var summary ScriptSummary
+	dryRun := runRequest(r.Context(), request, func(response CommandResponse) {
+		summary.Total++
+		if response.Error != "" {
+			summary.Failed++
+		}
+		writeEvent(w, "command", response)
+		flusher.Flush()
+	})
+	// Si el cliente cerró la conexión no se ejecuta el resto del script
+	if r.Context().Err() != nil {
+		log.Println("Cliente desconectado, se detiene el script")
+		return
+	}
+	if dryRun != nil {
+		writeEvent(w, "dryrun", dryRun)
+	}
+
+	summary.DurationMs = float64(time.Since(inicio).Microseconds()) / 1000
+	writeEvent(w, "done", summary)
+	flusher.Flush()
+}
+
+// Funcion para escribir un evento de Server-Sent Events con datos en JSON
+func writeEvent(w http.ResponseWriter, event string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.Println("Error codificando evento:", err)
+		return
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+}
@@ -0,0 +1,465 @@
+package Analyzer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"proyecto1/DiskManagement"
+	"proyecto1/Errors"
+	"proyecto1/FileSystem"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/webdav"
+)
+
+// Los comandos y las operaciones de archivos desde HTTP se hacen una a la vez, el escritor no admite accesos concurrentes
+// Todo lo que lea las particiones montadas o abra un disco debe tenerlo, también para no ver los discos de un dry-run
+var filesMutex sync.Mutex
+
+// Funcion para desmontar todas las particiones al apagar el servidor, espera a que termine el comando en curso
+func Clean() error {
+	filesMutex.Lock()
+	defer filesMutex.Unlock()
+	return DiskManagement.Clean()
+}
+
+// Bloqueos de WebDAV por partición montada
+var (
+	davLocks      = make(map[string]webdav.LockSystem)
+	davLocksMutex sync.Mutex
+)
+
+// WebDAVHandler sirve una partición montada y formateada por WebDAV en /webdav/{id}/
+// Se autentica con Basic auth contra /users.txt y cada operación valida los permisos UGO del usuario
+func WebDAVHandler(w http.ResponseWriter, r *http.Request) {
+	id := strings.ToLower(r.PathValue("id"))
+	user, err := authenticateRequest(r, id)
+	if err != nil {
+		if errors.Is(err, Errors.ErrDenied) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="MIA"`)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	davLocksMutex.Lock()
+	locks, ok := davLocks[id]
+	if !ok {
+		locks = webdav.NewMemLS()
+		davLocks[id] = locks
+	}
+	davLocksMutex.Unlock()
+
+	// El cuerpo se limita al tamaño máximo de un archivo, un PUT más grande no cabe en ningún inodo
+	r.Body = http.MaxBytesReader(w, r.Body, FileSystem.MaxFileSize)
+	fs := &partitionFS{id: id, user: user}
+	handler := &webdav.Handler{
+		Prefix:     "/webdav/" + r.PathValue("id"),
+		FileSystem: fs,
+		LockSystem: locks,
+	}
+	handler.ServeHTTP(&davResponse{ResponseWriter: w, fs: fs}, r)
+}
+
+// Respuesta de WebDAV que reporta 403 cuando una operación falló por permisos
+// webdav.Handler responde 404 o 405 a cualquier error de OpenFile, RemoveAll o Rename
+type davResponse struct {
+	http.ResponseWriter
+	fs        *partitionFS
+	forbidden bool
+}
+
+func (d *davResponse) WriteHeader(status int) {
+	if status >= http.StatusBadRequest && d.fs.denied != nil {
+		d.forbidden = true
+		http.Error(d.ResponseWriter, d.fs.denied.Error(), http.StatusForbidden)
+		return
+	}
+	d.ResponseWriter.WriteHeader(status)
+}
+
+func (d *davResponse) Write(data []byte) (int, error) {
+	if d.forbidden {
+		return len(data), nil
+	}
+	return d.ResponseWriter.Write(data)
+}
+
+// Funcion para validar las credenciales Basic auth de una petición contra /users.txt de la partición
+func authenticateRequest(r *http.Request, id string) (FileSystem.User, error) {
+	name, password, ok := r.BasicAuth()
+	if !ok {
+		return FileSystem.User{}, &Errors.DiskError{Kind: Errors.ErrDenied, Message: "Se requiere usuario y contraseña"}
+	}
+
+	filesMutex.Lock()
+	defer filesMutex.Unlock()
+	file, _, sb, err := openPartition(id)
+	if err != nil {
+		return FileSystem.User{}, err
+	}
+	defer file.Close()
+	return FileSystem.Authenticate(file, sb, name, password)
+}
+
+// Funcion para abrir el disco de una partición montada y leer su superbloque
+func openPartition(id string) (Utilities.BlockDevice, Structs.Partition, Structs.Superblock, error) {
+	particion, pathDisco, err := DiskManagement.GetPartitionByID(id)
+	if err != nil {
+		return nil, particion, Structs.Superblock{}, err
+	}
+	file, err := DiskManagement.OpenDisk(pathDisco)
+	if err != nil {
+		return nil, particion, Structs.Superblock{}, err
+	}
+	sb, err := FileSystem.ReadSuperblock(file, particion)
+	if err != nil {
+		file.Close()
+		return nil, particion, sb, err
+	}
+	return file, particion, sb, nil
+}
+
+// Sistema de archivos de WebDAV sobre una partición montada, con los permisos de un usuario
+// denied guarda el último error de permisos para responderlo como 403
+type partitionFS struct {
+	id     string
+	user   FileSystem.User
+	denied error
+}
+
+// Funcion para ejecutar una operación de lectura sobre la partición
+func (p *partitionFS) read(fn func(file Utilities.BlockDevice, sb Structs.Superblock) error) error {
+	filesMutex.Lock()
+	defer filesMutex.Unlock()
+	file, _, sb, err := openPartition(p.id)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return p.davError(fn(file, sb))
+}
+
+// Funcion para ejecutar una operación de escritura sobre la partición, el superbloque se guarda al terminar
+func (p *partitionFS) write(fn func(file Utilities.BlockDevice, sb Structs.Superblock, writer *FileSystem.Writer) error) error {
+	filesMutex.Lock()
+	defer filesMutex.Unlock()
+	file, particion, sb, err := openPartition(p.id)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer, err := FileSystem.NewWriter(file, particion)
+	if err != nil {
+		return err
+	}
+	err = fn(file, sb, writer)
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	return p.davError(err)
+}
+
+// Funcion para obtener la carpeta padre de una ruta y validar que el usuario pueda escribir en ella
+func (p *partitionFS) writableParent(file Utilities.BlockDevice, sb Structs.Superblock, name string) (int32, error) {
+	parent, err := FileSystem.Stat(file, sb, path.Dir(name))
+	if err != nil {
+		return -1, err
+	}
+	if !parent.IsDir() {
+		return -1, os.ErrNotExist
+	}
+	if !p.user.CanWrite(parent.Inode()) {
+		return -1, FileSystem.PermissionDenied(path.Dir(name))
+	}
+	return parent.Index(), nil
+}
+
+func (p *partitionFS) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	name = path.Clean("/" + name)
+	return p.write(func(file Utilities.BlockDevice, sb Structs.Superblock, writer *FileSystem.Writer) error {
+		if _, err := FileSystem.Stat(file, sb, name); err == nil {
+			return os.ErrExist
+		}
+		parent, err := p.writableParent(file, sb, name)
+		if err != nil {
+			return err
+		}
+		_, err = writer.Mkdir(parent, path.Base(name), p.user.UID, p.user.GID, "775")
+		return err
+	})
+}
+
+func (p *partitionFS) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	name = path.Clean("/" + name)
+	writable := flag&(os.O_WRONLY|os.O_RDWR) != 0
+
+	var opened *davFile
+	open := func(file Utilities.BlockDevice, sb Structs.Superblock) error {
+		info, err := FileSystem.Stat(file, sb, name)
+		if err != nil {
+			return err
+		}
+		if flag&os.O_EXCL != 0 && flag&os.O_CREATE != 0 {
+			return os.ErrExist
+		}
+		if (writable && !p.user.CanWrite(info.Inode())) || (!writable && !p.user.CanRead(info.Inode())) {
+			return FileSystem.PermissionDenied(name)
+		}
+
+		opened = &davFile{fs: p, name: name, info: info, writable: writable, limit: writeLimit(sb)}
+		if info.IsDir() {
+			if writable {
+				return os.ErrPermission
+			}
+			children, err := FileSystem.ReadDirInfo(file, sb, info.Inode())
+			if err != nil {
+				return err
+			}
+			for _, child := range children {
+				opened.children = append(opened.children, child)
+			}
+			return nil
+		}
+		if flag&os.O_TRUNC == 0 {
+			if opened.data, err = FileSystem.ReadFileBytes(file, sb, info.Inode()); err != nil {
+				return err
+			}
+		}
+		opened.dirty = flag&os.O_TRUNC != 0
+		return nil
+	}
+
+	err := p.read(open)
+	if errors.Is(err, os.ErrNotExist) && flag&os.O_CREATE != 0 {
+		// El archivo se crea vacío para que exista mientras se escribe su contenido
+		err = p.write(func(file Utilities.BlockDevice, sb Structs.Superblock, writer *FileSystem.Writer) error {
+			parent, err := p.writableParent(file, sb, name)
+			if err != nil {
+				return err
+			}
+			if _, err := writer.WriteFile(parent, path.Base(name), nil, p.user.UID, p.user.GID, "664"); err != nil {
+				return err
+			}
+			return nil
+		})
+		if err == nil {
+			err = p.read(open)
+		}
+		if opened != nil {
+			opened.created = true
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return opened, nil
+}
+
+func (p *partitionFS) RemoveAll(ctx context.Context, name string) error {
+	name = path.Clean("/" + name)
+	if name == "/" {
+		return os.ErrPermission
+	}
+	return p.write(func(file Utilities.BlockDevice, sb Structs.Superblock, writer *FileSystem.Writer) error {
+		parent, err := p.writableParent(file, sb, name)
+		if err != nil {
+			return err
+		}
+		target, err := FileSystem.Stat(file, sb, name)
+		if err != nil {
+			return err
+		}
+		if err := p.user.CanRemove(file, sb, target.Index(), name); err != nil {
+			return err
+		}
+		return writer.Remove(parent, path.Base(name))
+	})
+}
+
+func (p *partitionFS) Rename(ctx context.Context, oldName, newName string) error {
+	oldName, newName = path.Clean("/"+oldName), path.Clean("/"+newName)
+	if oldName == "/" || newName == "/" {
+		return os.ErrPermission
+	}
+	return p.write(func(file Utilities.BlockDevice, sb Structs.Superblock, writer *FileSystem.Writer) error {
+		oldParent, err := p.writableParent(file, sb, oldName)
+		if err != nil {
+			return err
+		}
+		newParent, err := p.writableParent(file, sb, newName)
+		if err != nil {
+			return err
+		}
+		return writer.Rename(oldParent, path.Base(oldName), newParent, path.Base(newName))
+	})
+}
+
+func (p *partitionFS) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	name = path.Clean("/" + name)
+	var info FileSystem.FileInfo
+	err := p.read(func(file Utilities.BlockDevice, sb Structs.Superblock) error {
+		var err error
+		info, err = FileSystem.Stat(file, sb, name)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// Funcion para obtener cuántos bytes puede tener un archivo abierto para escritura
+// El contenido nuevo se escribe en bloques libres antes de liberar los actuales, así que el límite son los bloques libres
+func writeLimit(sb Structs.Superblock) int64 {
+	limit := int64(sb.S_free_blocks_count) * int64(sb.S_block_size)
+	if limit > FileSystem.MaxFileSize {
+		limit = FileSystem.MaxFileSize
+	}
+	return limit
+}
+
+// Archivo o carpeta abierta por WebDAV, el contenido se mantiene en memoria y se escribe en la partición al cerrar
+// created indica que el archivo se creó vacío al abrirlo y limit es el tamaño máximo que puede alcanzar al escribir
+type davFile struct {
+	fs       *partitionFS
+	name     string
+	info     FileSystem.FileInfo
+	children []os.FileInfo
+	data     []byte
+	offset   int64
+	limit    int64
+	writable bool
+	dirty    bool
+	created  bool
+}
+
+func (f *davFile) Read(p []byte) (int, error) {
+	if f.info.IsDir() {
+		return 0, os.ErrInvalid
+	}
+	if f.offset >= int64(len(f.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.data[f.offset:])
+	f.offset += int64(n)
+	return n, nil
+}
+
+func (f *davFile) Write(p []byte) (int, error) {
+	if !f.writable {
+		return 0, os.ErrPermission
+	}
+	end := f.offset + int64(len(p))
+	// Se rechaza antes de reservar memoria, un Seek lejos del final no debe crecer el buffer sin límite
+	if end > f.limit {
+		return 0, &Errors.DiskError{Kind: Errors.ErrNoSpace, Code: "filesystem_full", Message: fmt.Sprintf("%s no cabe en la partición, el máximo que puede escribirse es %d bytes", f.name, f.limit)}
+	}
+	if end > int64(len(f.data)) {
+		f.data = append(f.data, make([]byte, end-int64(len(f.data)))...)
+	}
+	copy(f.data[f.offset:], p)
+	f.offset = end
+	f.dirty = true
+	return len(p), nil
+}
+
+func (f *davFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += int64(len(f.data))
+	default:
+		return 0, os.ErrInvalid
+	}
+	if offset < 0 {
+		return 0, os.ErrInvalid
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *davFile) Readdir(count int) ([]os.FileInfo, error) {
+	if !f.info.IsDir() {
+		return nil, os.ErrInvalid
+	}
+	if count <= 0 {
+		children := f.children
+		f.children = nil
+		return children, nil
+	}
+	if len(f.children) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(f.children) {
+		count = len(f.children)
+	}
+	children := f.children[:count]
+	f.children = f.children[count:]
+	return children, nil
+}
+
+func (f *davFile) Stat() (os.FileInfo, error) {
+	if f.dirty {
+		return sizedInfo{f.info, int64(len(f.data))}, nil
+	}
+	return f.info, nil
+}
+
+func (f *davFile) Close() error {
+	if !f.dirty {
+		return nil
+	}
+	f.dirty = false
+	return f.fs.write(func(file Utilities.BlockDevice, sb Structs.Superblock, writer *FileSystem.Writer) error {
+		parent, err := FileSystem.Stat(file, sb, path.Dir(f.name))
+		if err != nil {
+			return err
+		}
+		inode := f.info.Inode()
+		_, err = writer.WriteFile(parent.Index(), path.Base(f.name), bytes.Clone(f.data), inode.I_uid, inode.I_gid, string(inode.I_perm[:]))
+		// Si falla, un archivo que existía conserva su contenido y uno que se creó al abrirlo se elimina
+		if err != nil && f.created {
+			writer.Remove(parent.Index(), path.Base(f.name))
+		}
+		return err
+	})
+}
+
+// Información de un archivo con el tamaño del contenido que aún no se ha escrito
+type sizedInfo struct {
+	os.FileInfo
+	size int64
+}
+
+func (s sizedInfo) Size() int64 {
+	return s.size
+}
+
+// Funcion para convertir los errores de disco a los errores que WebDAV traduce a códigos HTTP
+func (p *partitionFS) davError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, Errors.ErrNotFound):
+		return os.ErrNotExist
+	case errors.Is(err, Errors.ErrDenied):
+		p.denied = err
+		return os.ErrPermission
+	default:
+		return err
+	}
+}
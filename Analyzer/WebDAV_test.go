@@ -0,0 +1,33 @@
+package Analyzer
+
+import (
+	"errors"
+	"io"
+	"proyecto1/Errors"
+	"testing"
+)
+
+func TestDavFileWriteLimit(t *testing.T) {
+	file := &davFile{name: "/a.txt", writable: true, limit: 128}
+	if _, err := file.Write(make([]byte, 100)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Un Seek lejos del final seguido de un Write no debe reservar memoria más allá del límite
+	if _, err := file.Seek(1<<40, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.Write([]byte("x")); !errors.Is(err, Errors.ErrNoSpace) {
+		t.Fatalf("Se esperaba un error de espacio insuficiente y se obtuvo %v", err)
+	}
+	if len(file.data) != 100 {
+		t.Errorf("El contenido creció a %d bytes", len(file.data))
+	}
+
+	if _, err := file.Seek(100, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.Write(make([]byte, 29)); !errors.Is(err, Errors.ErrNoSpace) {
+		t.Fatalf("Se esperaba un error al pasar de %d bytes y se obtuvo %v", file.limit, err)
+	}
+}
@@ -3,9 +3,13 @@ package DiskManagement
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"math"
 	"math/rand"
+	"os"
 	"proyecto1/Structs"
 	"proyecto1/Utilities"
 	"strings"
@@ -47,36 +51,27 @@ func GetMountedPartitions() map[string][]MountedPartition{
 		return mountedPartitions
 }
 
-func Mkdisk(size int, fit string, unit string, path string) {
+func Mkdisk(size int, fit string, unit string, path string, prealloc bool) error {
 	fmt.Println("======INICIO MKDISK======")
 	fmt.Println("Size:", size)
 	fmt.Println("Fit:", fit)
 	fmt.Println("Unit:", unit)
 	fmt.Println("Path:", path)
+	fmt.Println("Prealloc:", prealloc)
 
 	// Validar fit bf/ff/wf
 	if fit != "bf" && fit != "wf" && fit != "ff" {
-		fmt.Println("Error: El Fit debe ser bf, wf or ff")
-		return
+		return invalidError("El Fit debe ser bf, wf or ff")
 	}
 
 	// Validar size > 0
 	if size <= 0 {
-		fmt.Println("Error: Size debe ser mayor a  0")
-		return
+		return invalidError("Size debe ser mayor a 0")
 	}
 
 	// Validar unidar k - m
 	if unit != "k" && unit != "m" {
-		fmt.Println("Error: Las unidades validas son k o m")
-		return
-	}
-
-	// Create file
-	err := Utilities.CreateFile(path)
-	if err != nil {
-		fmt.Println("Error: ", err)
-		return
+		return invalidError("Las unidades validas son k o m")
 	}
 
 	/*
@@ -90,19 +85,37 @@ func Mkdisk(size int, fit string, unit string, path string) {
 		size = size * 1024 * 1024
 	}
 
+	// El tamaño del MBR es int32, no se pueden crear discos de 2 GB o más
+	// Se valida antes de crear el archivo para no dejar un archivo vacío en la ruta
+	if size > math.MaxInt32 {
+		return withCode("disk_too_large", invalidError("El tamaño del disco debe ser menor a 2 GB"))
+	}
+
+	// Create file
+	err := Utilities.CreateFile(path)
+	if err != nil {
+		return ioError(err, "No se pudo crear el archivo en la ruta: %s", path)
+	}
+
 	// Open bin file
 	file, err := Utilities.OpenFile(path)
 	if err != nil {
-		return
+		return ioError(err, "No se pudo abrir el archivo en la ruta: %s", path)
 	}
+	defer file.Close()
 
-	// Escribir los 0 en el archivo
+	// El archivo se trunca al tamaño del disco, el sistema lo crea disperso y lleno de 0
+	if err := file.Truncate(0); err != nil {
+		return ioError(err, "No se pudo inicializar el disco")
+	}
+	if err := file.Truncate(int64(size)); err != nil {
+		return ioError(err, "No se pudo asignar el tamaño del disco")
+	}
 
-	// create array of byte(0)
-	for i := 0; i < size; i++ {
-		err := Utilities.WriteObject(file, byte(0), int64(i))
-		if err != nil {
-			fmt.Println("Error: ", err)
+	// Con -prealloc se escriben los 0 en bloques grandes para reservar el espacio real en el disco
+	if prealloc {
+		if err := Utilities.WriteZeros(file, int64(size)); err != nil {
+			return ioError(err, "No se pudo reservar el espacio del disco")
 		}
 	}
 
@@ -131,23 +144,25 @@ func Mkdisk(size int, fit string, unit string, path string) {
 	*/
 
 	// Escribir el archivo
-	if err := Utilities.WriteObject(file, newMRB, 0); err != nil {
-		return
+	if err := writeMBR(file, newMRB); err != nil {
+		return err
 	}
 
-	var TempMBR Structs.MRB
 	// Leer el archivo
-	if err := Utilities.ReadObject(file, &TempMBR, 0); err != nil {
-		return
+	TempMBR, err := readMBR(file)
+	if err != nil {
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		return ioError(err, "No se pudo guardar el disco")
 	}
 
 	// Print object
 	Structs.PrintMBR(TempMBR)
 
-	// Cerrar el archivo
-	defer file.Close()
-
 	fmt.Println("======FIN MKDISK======")
+	return nil
 }
 
 func Rmdisk(path string) error {
@@ -156,9 +171,11 @@ func Rmdisk(path string) error {
 
 	// Delete file
 	err := Utilities.DeleteFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return withCode("disk_not_found", notFoundError("No existe un disco en la ruta: %s", path))
+	}
 	if err != nil {
-		fmt.Println("Error: ", err)
-		return err
+		return ioError(err, "No se pudo eliminar el disco en la ruta: %s", path)
 	}
 
 	return nil
@@ -176,17 +193,17 @@ func Fdisk(size int, path string, name string, unit string, type_ string, fit st
 
 	// Validar fit (b/w/f)
 	if fit != "bf" && fit != "ff" && fit != "wf" {
-		return fmt.Errorf("El fit debe ser 'bf', 'ff', o 'wf'")
+		return invalidError("El fit debe ser 'bf', 'ff', o 'wf'")
 	}
 
 	// Validar size > 0
 	if size <= 0 {
-		return fmt.Errorf("El tamaño debe ser mayor a 0")
+		return invalidError("El tamaño debe ser mayor a 0")
 	}
 
 	// Validar unit (b/k/m)
 	if unit != "b" && unit != "k" && unit != "m" {
-		return fmt.Errorf("La unidad debe ser 'b', 'k', o 'm'")
+		return invalidError("La unidad debe ser 'b', 'k', o 'm'")
 	}
 
 	// Ajustar el tamaño en bytes
@@ -197,15 +214,16 @@ func Fdisk(size int, path string, name string, unit string, type_ string, fit st
 	}
 
 	// Abrir el archivo binario en la ruta proporcionada
-	file, err := Utilities.OpenFile(path)
+	file, err := OpenDisk(path)
 	if err != nil {
-		return fmt.Errorf("No se pudo abrir el archivo en la ruta: %s", path)
+		return err
 	}
+	defer file.Close()
 
-	var TempMBR Structs.MRB
 	// Leer el objeto desde el archivo binario
-	if err := Utilities.ReadObject(file, &TempMBR, 0); err != nil {
-		return fmt.Errorf("No se pudo leer el MBR desde el archivo")
+	TempMBR, err := readMBR(file)
+	if err != nil {
+		return err
 	}
 
 	// Imprimir el objeto MBR
@@ -235,28 +253,28 @@ func Fdisk(size int, path string, name string, unit string, type_ string, fit st
 		// Truncar los caracteres nulos en el nombre de la partición antes de la comparación
 		partitionName := strings.TrimRight(string(TempMBR.Partitions[i].Name[:]), "\x00")
 		if partitionName == name {
-			return fmt.Errorf("Ya existe una partición con el nombre '%s'", name)
+			return withCode("partition_exists", invalidError("Ya existe una partición con el nombre '%s'", name))
 		}
 	}
 
 	// Validar que no se exceda el número máximo de particiones primarias y extendidas
 	if totalPartitions >= 4 {
-		return fmt.Errorf("No se pueden crear más de 4 particiones primarias o extendidas en total.")
+		return withCode("partition_limit", noSpaceError("No se pueden crear más de 4 particiones primarias o extendidas en total."))
 	}
 
 	// Validar que solo haya una partición extendida
 	if type_ == "e" && extendedCount > 0 {
-		return fmt.Errorf("Solo se permite una partición extendida por disco.")
+		return withCode("extended_exists", invalidError("Solo se permite una partición extendida por disco."))
 	}
 
 	// Validar que no se pueda crear una partición lógica sin una extendida
 	if type_ == "l" && extendedCount == 0 {
-		return fmt.Errorf("No se puede crear una partición lógica sin una partición extendida.")
+		return withCode("extended_not_found", notFoundError("No se puede crear una partición lógica sin una partición extendida."))
 	}
 
 	// Validar que el tamaño de la nueva partición no exceda el tamaño del disco
 	if usedSpace+int32(size) > TempMBR.MbrSize {
-		return fmt.Errorf("No hay suficiente espacio en el disco para crear esta partición.")
+		return withCode("disk_full", noSpaceError("No hay suficiente espacio en el disco para crear esta partición."))
 	}
 
 	// Determinar la posición de inicio de la nueva partición
@@ -288,7 +306,9 @@ func Fdisk(size int, path string, name string, unit string, type_ string, fit st
 						PartNext:  -1,
 					}
 					copy(ebr.PartName[:], "")
-					Utilities.WriteObject(file, ebr, int64(ebrStart))
+					if err := writeEBR(file, ebr, ebrStart); err != nil {
+						return err
+					}
 				}
 
 				break
@@ -300,23 +320,29 @@ func Fdisk(size int, path string, name string, unit string, type_ string, fit st
 	if type_ == "l" {
 		for i := 0; i < 4; i++ {
 			if TempMBR.Partitions[i].Type[0] == 'e' {
-				ebrPos := TempMBR.Partitions[i].Start
-				var ebr Structs.EBR
-				for {
-					Utilities.ReadObject(file, &ebr, int64(ebrPos))
-					if ebr.PartNext == -1 {
-						break
-					}
-					ebrPos = ebr.PartNext
+				// Recorrer la cadena de EBRs hasta el último
+				chain, positions, err := readEBRChain(file, TempMBR.Partitions[i])
+				if err != nil {
+					return err
 				}
+				ebr := chain[len(chain)-1]
+				ebrPos := positions[len(positions)-1]
 
 				// Calcular la posición de inicio de la nueva partición lógica
 				newEBRPos := ebr.PartStart + ebr.PartSize                    // El nuevo EBR se coloca después de la partición lógica anterior
 				logicalPartitionStart := newEBRPos + int32(binary.Size(ebr)) // El inicio de la partición lógica es justo después del EBR
 
+				// Validar que la nueva partición lógica quepa dentro de la extendida
+				extendedEnd := TempMBR.Partitions[i].Start + TempMBR.Partitions[i].Size
+				if logicalPartitionStart+int32(size) > extendedEnd {
+					return withCode("extended_full", noSpaceError("No hay suficiente espacio en la partición extendida para crear esta partición lógica."))
+				}
+
 				// Ajustar el siguiente EBR
 				ebr.PartNext = newEBRPos
-				Utilities.WriteObject(file, ebr, int64(ebrPos))
+				if err := writeEBR(file, ebr, ebrPos); err != nil {
+					return err
+				}
 
 				// Crear y escribir el nuevo EBR
 				newEBR := Structs.EBR{
@@ -326,7 +352,9 @@ func Fdisk(size int, path string, name string, unit string, type_ string, fit st
 					PartNext:  -1,
 				}
 				copy(newEBR.PartName[:], name)
-				Utilities.WriteObject(file, newEBR, int64(newEBRPos))
+				if err := writeEBR(file, newEBR, newEBRPos); err != nil {
+					return err
+				}
 
 				// Imprimir el nuevo EBR creado
 				fmt.Println("Nuevo EBR creado:")
@@ -335,18 +363,12 @@ func Fdisk(size int, path string, name string, unit string, type_ string, fit st
 
 				// Imprimir todos los EBRs en la partición extendida
 				fmt.Println("Imprimiendo todos los EBRs en la partición extendida:")
-				ebrPos = TempMBR.Partitions[i].Start
-				for {
-					err := Utilities.ReadObject(file, &ebr, int64(ebrPos))
-					if err != nil {
-						fmt.Println("Error al leer EBR:", err)
-						break
-					}
+				chain, _, err = readEBRChain(file, TempMBR.Partitions[i])
+				if err != nil {
+					return err
+				}
+				for _, ebr := range chain {
 					Structs.PrintEBR(ebr)
-					if ebr.PartNext == -1 {
-						break
-					}
-					ebrPos = ebr.PartNext
 				}
 
 				break
@@ -356,22 +378,19 @@ func Fdisk(size int, path string, name string, unit string, type_ string, fit st
 	}
 
 	// Sobrescribir el MBR
-	if err := Utilities.WriteObject(file, TempMBR, 0); err != nil {
-		return fmt.Errorf("No se pudo escribir el MBR en el archivo")
+	if err := writeMBR(file, TempMBR); err != nil {
+		return err
 	}
 
-	var TempMBR2 Structs.MRB
 	// Leer el objeto nuevamente para verificar
-	if err := Utilities.ReadObject(file, &TempMBR2, 0); err != nil {
-		return fmt.Errorf("No se pudo leer el MBR desde el archivo")
+	TempMBR2, err := readMBR(file)
+	if err != nil {
+		return err
 	}
 
 	// Imprimir el objeto MBR actualizado
 	Structs.PrintMBR(TempMBR2)
 
-	// Cerrar el archivo binario
-	defer file.Close()
-
 	fmt.Println("======FIN FDISK======")
 	fmt.Println("")
 	return nil
@@ -379,15 +398,15 @@ func Fdisk(size int, path string, name string, unit string, type_ string, fit st
 
 // Función para montar particiones
 func Mount(path string, name string) error {
-	file, err := Utilities.OpenFile(path)
+	file, err := OpenDisk(path)
 	if err != nil {
-		return fmt.Errorf("No se pudo abrir el archivo en la ruta: %s", path)
+		return err
 	}
 	defer file.Close()
 
-	var TempMBR Structs.MRB
-	if err := Utilities.ReadObject(file, &TempMBR, 0); err != nil {
-		return fmt.Errorf("No se pudo leer el MBR desde el archivo")
+	TempMBR, err := readMBR(file)
+	if err != nil {
+		return err
 	}
 
 	log.Println("Buscando partición con nombre:", name)
@@ -409,17 +428,17 @@ func Mount(path string, name string) error {
 		}
 		// Si se encuentra pero no es primaria, dar mensaje de error indicando que solo las primarias se pueden montar
 		if bytes.Equal(TempMBR.Partitions[i].Name[:], nameBytes[:]) {
-			return fmt.Errorf("Solo se pueden montar particiones primarias")
+			return withCode("not_primary", invalidError("Solo se pueden montar particiones primarias"))
 		}
 	}
 
 	if !partitionFound {
-		return fmt.Errorf("No se encontró una partición con el nombre: '%s'", name)
+		return withCode("partition_not_found", notFoundError("No se encontró una partición con el nombre: '%s'", name))
 	}
 
 	// Verificar si la partición ya está montada
 	if partition.Status[0] == '1' {
-		return fmt.Errorf("La partición ya está montada")
+		return withCode("already_mounted", invalidError("La partición ya está montada"))
 	}
 
 	//fmt.Printf("Partición encontrada: '%s' en posición %d\n", string(partition.Name[:]), partitionIndex+1)
@@ -454,6 +473,12 @@ func Mount(path string, name string) error {
 	partition.Status[0] = '1'
 	copy(partition.Id[:], partitionID)
 	TempMBR.Partitions[partitionIndex] = partition
+
+	// Escribir el MBR actualizado al archivo, la partición solo se registra si la escritura fue exitosa
+	if err := writeMBR(file, TempMBR); err != nil {
+		return err
+	}
+
 	mountedPartitions[diskID] = append(mountedPartitions[diskID], MountedPartition{
 		Path:   path,
 		Name:   name,
@@ -461,12 +486,7 @@ func Mount(path string, name string) error {
 		Status: '1',
 	})
 
-	// Escribir el MBR actualizado al archivo
-	if err := Utilities.WriteObject(file, TempMBR, 0); err != nil {
-		return fmt.Errorf("No se pudo escribir el MBR en el archivo")
-	}
-
-	log.Println("Partición montada con ID: %s\n", partitionID)
+	log.Printf("Partición montada con ID: %s\n", partitionID)
 
 	// Imprimir el MBR actualizado
 	log.Println("MBR actualizado:")
@@ -479,6 +499,47 @@ func Mount(path string, name string) error {
 
 }
 
+// Función para obtener una partición montada por su ID, devuelve la partición del MBR y la ruta del disco
+func GetPartitionByID(id string) (Structs.Partition, string, error) {
+	var mounted MountedPartition
+	found := false
+	for _, partitions := range mountedPartitions {
+		for _, partition := range partitions {
+			// Los IDs no distinguen mayúsculas
+			if strings.EqualFold(partition.ID, id) {
+				mounted = partition
+				found = true
+			}
+		}
+	}
+
+	if !found {
+		return Structs.Partition{}, "", withCode("not_mounted", notFoundError("La partición con ID %s no está montada", id))
+	}
+
+	file, err := OpenDisk(mounted.Path)
+	if err != nil {
+		return Structs.Partition{}, "", err
+	}
+	defer file.Close()
+
+	TempMBR, err := readMBR(file)
+	if err != nil {
+		return Structs.Partition{}, "", err
+	}
+
+	nameBytes := [16]byte{}
+	copy(nameBytes[:], []byte(mounted.Name))
+
+	for i := 0; i < 4; i++ {
+		if bytes.Equal(TempMBR.Partitions[i].Name[:], nameBytes[:]) {
+			return TempMBR.Partitions[i], mounted.Path, nil
+		}
+	}
+
+	return Structs.Partition{}, "", withCode("partition_not_found", notFoundError("No se encontró la partición '%s' en el disco %s", mounted.Name, mounted.Path))
+}
+
 // Función para obtener el ID del último disco montado
 func getLastDiskID() string {
 	var lastDiskID string
@@ -493,38 +554,156 @@ func generateDiskID(path string) string {
 }
 
 // Funcion Clean, desmonta todas las particiones montadas. Debe cambiar el estatus de todas las particiones a 0
-func Clean() {
+func Clean() error {
 	log.Println("Limpiando particiones montadas...")
+	var errs []error
 	for diskID, partitions := range mountedPartitions {
-		file, err := Utilities.OpenFile(partitions[0].Path)
-		if err != nil {
-			log.Println("No se pudo abrir el archivo en la ruta:", partitions[0].Path)
+		if err := unmountDisk(partitions[0].Path); err != nil {
+			log.Println(err)
+			errs = append(errs, err)
 			continue
 		}
+		log.Println("Particiones desmontadas en disco:", diskID)
+	}
 
-		var TempMBR Structs.MRB
-		if err := Utilities.ReadObject(file, &TempMBR, 0); err != nil {
-			log.Println("No se pudo leer el MBR desde el archivo")
-			continue
-		}
+	// Limpiar el mapa de particiones montadas
+	mountedPartitions = make(map[string][]MountedPartition)
+	log.Println("Particiones montadas limpiadas.")
+	return errors.Join(errs...)
+}
 
-		for i := 0; i < 4; i++ {
-			if TempMBR.Partitions[i].Size != 0 {
-				TempMBR.Partitions[i].Status[0] = '0'
-				TempMBR.Partitions[i].Id = [4]byte{}
+// Funcion para marcar como desmontadas todas las particiones primarias de un disco
+func unmountDisk(path string) error {
+	file, err := OpenDisk(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	TempMBR, err := readMBR(file)
+	if err != nil {
+		return err
+	}
+
+	unmountPartitions(&TempMBR)
+
+	if err := writeMBR(file, TempMBR); err != nil {
+		return err
+	}
+	Structs.PrintMBR(TempMBR)
+	return nil
+}
+
+// Funcion para abrir un disco existente, distingue un disco inexistente de un error de lectura
+func OpenDisk(path string) (Utilities.BlockDevice, error) {
+	file, err := Utilities.OpenFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, withCode("disk_not_found", notFoundError("No existe un disco en la ruta: %s", path))
+	}
+	if err != nil {
+		return nil, ioError(err, "No se pudo abrir el archivo en la ruta: %s", path)
+	}
+	return file, nil
+}
+
+// Funcion para leer el MBR y la cadena de EBRs de la partición extendida de un disco
+func ReadDisk(path string) (Structs.MRB, []Structs.EBR, error) {
+	file, err := OpenDisk(path)
+	if err != nil {
+		return Structs.MRB{}, nil, err
+	}
+	defer file.Close()
+
+	TempMBR, err := readMBR(file)
+	if err != nil {
+		return TempMBR, nil, err
+	}
+
+	var ebrs []Structs.EBR
+	for i := 0; i < 4; i++ {
+		if TempMBR.Partitions[i].Size > 0 && TempMBR.Partitions[i].Type[0] == 'e' {
+			chain, _, err := readEBRChain(file, TempMBR.Partitions[i])
+			if err != nil {
+				return TempMBR, nil, err
 			}
+			ebrs = append(ebrs, chain...)
 		}
+	}
+	return TempMBR, ebrs, nil
+}
 
-		if err := Utilities.WriteObject(file, TempMBR, 0); err != nil {
-			log.Println("No se pudo escribir el MBR en el archivo")
-			continue
+// Funcion para leer el MBR de un disco y validar que sus valores sean coherentes con el tamaño del archivo
+func readMBR(file Utilities.BlockDevice) (Structs.MRB, error) {
+	var mbr Structs.MRB
+	if err := Utilities.ReadObject(file, &mbr, 0); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return mbr, withCode("invalid_mbr", corruptError("El disco es demasiado pequeño para contener un MBR"))
 		}
+		return mbr, ioError(err, "No se pudo leer el MBR desde el archivo")
+	}
 
-		log.Println("Particiones desmontadas en disco:", diskID)
-		Structs.PrintMBR(TempMBR)
+	size, err := file.Size()
+	if err != nil {
+		return mbr, ioError(err, "No se pudo obtener el tamaño del disco")
 	}
+	if mbr.Checksum != Utilities.Checksum(mbr) {
+		return mbr, withCode("invalid_mbr", corruptError("El checksum del MBR no coincide, el archivo no es un disco válido o está dañado. "+checksumHint))
+	}
+	if mbr.MbrSize <= int32(binary.Size(mbr)) || int64(mbr.MbrSize) > size {
+		return mbr, withCode("invalid_mbr", corruptError("El MBR indica un tamaño de disco inválido: %d bytes", mbr.MbrSize))
+	}
+	return mbr, nil
+}
 
-	// Limpiar el mapa de particiones montadas
-	mountedPartitions = make(map[string][]MountedPartition)
-	log.Println("Particiones montadas limpiadas.")
+// Funcion para escribir el MBR al inicio del disco con su checksum actualizado
+func writeMBR(file Utilities.BlockDevice, mbr Structs.MRB) error {
+	mbr.Checksum = Utilities.Checksum(mbr)
+	if err := Utilities.WriteObject(file, mbr, 0); err != nil {
+		return ioError(err, "No se pudo escribir el MBR en el archivo")
+	}
+	return nil
+}
+
+// Funcion para escribir un EBR en la posición dada con su checksum actualizado
+func writeEBR(file Utilities.BlockDevice, ebr Structs.EBR, position int32) error {
+	ebr.Checksum = Utilities.Checksum(ebr)
+	if err := Utilities.WriteObject(file, ebr, int64(position)); err != nil {
+		return ioError(err, "No se pudo escribir el EBR en la posición %d", position)
+	}
+	return nil
+}
+
+// Funcion para leer la cadena de EBRs de una partición extendida junto con la posición de cada uno
+// Un EBR fuera de la partición o una cadena que vuelve sobre sí misma se reportan como corruptos
+func readEBRChain(file Utilities.BlockDevice, extended Structs.Partition) ([]Structs.EBR, []int32, error) {
+	var chain []Structs.EBR
+	var positions []int32
+	visited := make(map[int32]bool)
+	extendedEnd := extended.Start + extended.Size
+
+	ebrPos := extended.Start
+	for {
+		if ebrPos < extended.Start || ebrPos+int32(binary.Size(Structs.EBR{})) > extendedEnd {
+			return nil, nil, withCode("invalid_ebr", corruptError("El EBR en la posición %d está fuera de la partición extendida", ebrPos))
+		}
+		if visited[ebrPos] {
+			return nil, nil, withCode("invalid_ebr", corruptError("La cadena de EBRs forma un ciclo en la posición %d", ebrPos))
+		}
+		visited[ebrPos] = true
+
+		var ebr Structs.EBR
+		if err := Utilities.ReadObject(file, &ebr, int64(ebrPos)); err != nil {
+			return nil, nil, ioError(err, "No se pudo leer el EBR en la posición %d", ebrPos)
+		}
+		if ebr.Checksum != Utilities.Checksum(ebr) {
+			return nil, nil, withCode("invalid_ebr", corruptError("El checksum del EBR en la posición %d no coincide. "+checksumHint, ebrPos))
+		}
+		chain = append(chain, ebr)
+		positions = append(positions, ebrPos)
+
+		if ebr.PartNext == -1 {
+			return chain, positions, nil
+		}
+		ebrPos = ebr.PartNext
+	}
 }
@@ -0,0 +1,137 @@
+package DiskManagement
+
+import (
+	"proyecto1/Utilities"
+)
+
+// Cambios que haría un dry-run en un disco
+// Partitions compara la tabla de particiones (MBR y EBRs) antes y después, sin incluir las que no cambiaron
+type DryRunChange struct {
+	Path         string            `json:"path"`
+	Created      bool              `json:"created"`
+	Deleted      bool              `json:"deleted"`
+	BytesWritten int64             `json:"bytes_written"`
+	Partitions   []PartitionChange `json:"partitions"`
+}
+
+// Change es added, removed o modified, Before y After son la partición antes y después del dry-run
+// Las lógicas se identifican con tipo l y estado el campo mount de su EBR
+type PartitionChange struct {
+	Change string           `json:"change"`
+	Name   string           `json:"name"`
+	Before *LayoutPartition `json:"before,omitempty"`
+	After  *LayoutPartition `json:"after,omitempty"`
+}
+
+// Particiones montadas al iniciar el dry-run, se restauran al terminar
+var dryRunMounts map[string][]MountedPartition
+
+// Funcion para iniciar un dry-run, mientras está activo los discos se escriben en vistas copy-on-write
+// Devuelve false si ya había un dry-run activo, en ese caso lo termina quien lo inició
+func BeginDryRun() bool {
+	if !Utilities.BeginDryRun() {
+		return false
+	}
+	dryRunMounts = make(map[string][]MountedPartition)
+	for diskID, partitions := range mountedPartitions {
+		dryRunMounts[diskID] = append([]MountedPartition{}, partitions...)
+	}
+	return true
+}
+
+// Funcion para terminar el dry-run, descarta los cambios y devuelve lo que habría cambiado en cada disco
+func EndDryRun() []DryRunChange {
+	disks := Utilities.EndDryRun()
+	defer Utilities.CloseDryRunBases()
+	mountedPartitions = dryRunMounts
+	dryRunMounts = nil
+
+	changes := []DryRunChange{}
+	for _, disk := range disks {
+		change := DryRunChange{
+			Path:         disk.Path,
+			Created:      disk.Created,
+			Deleted:      disk.Deleted,
+			BytesWritten: disk.Overlay.BytesWritten(),
+			Partitions:   []PartitionChange{},
+		}
+		// Un disco que solo se leyó no aparece en el reporte
+		if !change.Created && !change.Deleted && change.BytesWritten == 0 {
+			continue
+		}
+
+		var before, after []LayoutPartition
+		if disk.Base != nil {
+			before = layoutPartitions(disk.Base)
+		}
+		if !disk.Deleted {
+			after = layoutPartitions(disk.Overlay)
+		}
+		change.Partitions = diffPartitions(before, after)
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// Funcion para obtener las particiones primarias, extendidas y lógicas de un dispositivo
+// Un dispositivo sin MBR válido (vacío o dañado) no tiene particiones
+func layoutPartitions(file Utilities.BlockDevice) []LayoutPartition {
+	layout, err := dumpLayout(file)
+	if err != nil {
+		return nil
+	}
+
+	var partitions []LayoutPartition
+	for _, part := range layout.Partitions {
+		if part.Size > 0 {
+			partitions = append(partitions, part)
+		}
+	}
+	for _, ebr := range layout.EBRs {
+		if ebr.Size > 0 {
+			partitions = append(partitions, LayoutPartition{
+				Status: ebr.Mount,
+				Type:   "l",
+				Fit:    ebr.Fit,
+				Start:  ebr.Start,
+				Size:   ebr.Size,
+				Name:   ebr.Name,
+			})
+		}
+	}
+	return partitions
+}
+
+// Funcion para comparar dos tablas de particiones por nombre
+// Primero van las particiones nuevas o modificadas en el orden del disco y después las eliminadas
+func diffPartitions(before, after []LayoutPartition) []PartitionChange {
+	changes := []PartitionChange{}
+	previous := make(map[string]LayoutPartition)
+	for _, part := range before {
+		previous[part.Name] = part
+	}
+
+	current := make(map[string]bool)
+	for _, part := range after {
+		part := part
+		current[part.Name] = true
+		old, ok := previous[part.Name]
+		if !ok {
+			changes = append(changes, PartitionChange{Change: "added", Name: part.Name, After: &part})
+		} else if old != part {
+			changes = append(changes, PartitionChange{Change: "modified", Name: part.Name, Before: &old, After: &part})
+		}
+	}
+	for _, part := range before {
+		part := part
+		if !current[part.Name] {
+			changes = append(changes, PartitionChange{Change: "removed", Name: part.Name, Before: &part})
+		}
+	}
+	return changes
+}
+
+// Funcion para saber si hay un dry-run activo
+func DryRunActive() bool {
+	return Utilities.DryRunActive()
+}
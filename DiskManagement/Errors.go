@@ -0,0 +1,37 @@
+package DiskManagement
+
+import (
+	"fmt"
+	"proyecto1/Errors"
+)
+
+// Nota para los errores de checksum, los discos creados antes de agregar los checksums no los tienen
+const checksumHint = "Si el disco se creó antes de que se guardaran los checksums, fsck -path=<disco> -repair los recalcula"
+
+func invalidError(format string, args ...interface{}) error {
+	return &Errors.DiskError{Kind: Errors.ErrInvalid, Message: fmt.Sprintf(format, args...)}
+}
+
+func notFoundError(format string, args ...interface{}) error {
+	return &Errors.DiskError{Kind: Errors.ErrNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+func noSpaceError(format string, args ...interface{}) error {
+	return &Errors.DiskError{Kind: Errors.ErrNoSpace, Message: fmt.Sprintf(format, args...)}
+}
+
+func corruptError(format string, args ...interface{}) error {
+	return &Errors.DiskError{Kind: Errors.ErrCorrupt, Message: fmt.Sprintf(format, args...)}
+}
+
+func ioError(err error, format string, args ...interface{}) error {
+	return &Errors.DiskError{Kind: Errors.ErrIO, Message: fmt.Sprintf(format, args...), Err: err}
+}
+
+// Funcion para asignar el código específico a un error de disco
+func withCode(code string, err error) error {
+	if diskErr, ok := err.(*Errors.DiskError); ok {
+		diskErr.Code = code
+	}
+	return err
+}
@@ -0,0 +1,220 @@
+package DiskManagement
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"proyecto1/Errors"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+	"sort"
+	"strings"
+)
+
+// Funcion para verificar el MBR, sus particiones y la cadena de EBRs de un disco
+// Devuelve los problemas y las particiones del MBR como se revisaron, para revisar después sus sistemas de archivos
+// Con repair se corrigen el checksum y el tamaño del MBR, se recortan o eliminan las particiones inválidas
+// y se corta la cadena de EBRs en el primer EBR dañado
+func CheckDisk(path string, repair bool) ([]Errors.FsckIssue, [4]Structs.Partition, error) {
+	var partitions [4]Structs.Partition
+	file, err := OpenDisk(path)
+	if err != nil {
+		return nil, partitions, err
+	}
+	defer file.Close()
+
+	// El MBR se lee sin validar, readMBR rechazaría justo los discos que fsck debe revisar
+	TempMBR, issues, err := checkMBR(file, repair)
+	if err != nil || TempMBR == nil {
+		return issues, partitions, err
+	}
+	changed := len(issues) > 0
+
+	partitionIssues, partitionsChanged := checkPartitions(TempMBR, repair)
+	issues = append(issues, partitionIssues...)
+	if repair && (changed || partitionsChanged) {
+		if err := writeMBR(file, *TempMBR); err != nil {
+			return issues, TempMBR.Partitions, err
+		}
+	}
+
+	for i := 0; i < 4; i++ {
+		if TempMBR.Partitions[i].Size > 0 && TempMBR.Partitions[i].Type[0] == 'e' {
+			ebrIssues, err := checkEBRChain(file, TempMBR.Partitions[i], repair)
+			issues = append(issues, ebrIssues...)
+			if err != nil {
+				return issues, TempMBR.Partitions, err
+			}
+		}
+	}
+	return issues, TempMBR.Partitions, nil
+}
+
+// Funcion para verificar el checksum del MBR y el tamaño de disco que indica
+// Si el tamaño no es válido se revisan las particiones con el tamaño del archivo, que es el que se guarda con repair
+// Devuelve nil si el archivo es demasiado pequeño para contener un MBR, en ese caso no se puede revisar nada más
+func checkMBR(file Utilities.BlockDevice, repair bool) (*Structs.MRB, []Errors.FsckIssue, error) {
+	var mbr Structs.MRB
+	if err := Utilities.ReadObject(file, &mbr, 0); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, []Errors.FsckIssue{{Check: Errors.CheckMBR, Message: "El disco es demasiado pequeño para contener un MBR, no se puede revisar el resto del disco"}}, nil
+		}
+		return nil, nil, ioError(err, "No se pudo leer el MBR desde el archivo")
+	}
+	size, err := file.Size()
+	if err != nil {
+		return nil, nil, ioError(err, "No se pudo obtener el tamaño del disco")
+	}
+
+	var issues []Errors.FsckIssue
+	if mbr.Checksum != Utilities.Checksum(mbr) {
+		issues = append(issues, Errors.FsckIssue{Check: Errors.CheckMBR, Message: "El checksum del MBR no coincide", Repaired: repair})
+	}
+	if mbr.MbrSize <= int32(binary.Size(mbr)) || int64(mbr.MbrSize) > size {
+		if size > math.MaxInt32 {
+			size = math.MaxInt32
+		}
+		issues = append(issues, Errors.FsckIssue{
+			Check:    Errors.CheckMBR,
+			Message:  fmt.Sprintf("El MBR indica un tamaño de disco inválido (%d bytes), el archivo tiene %d bytes", mbr.MbrSize, size),
+			Repaired: repair,
+		})
+		mbr.MbrSize = int32(size)
+	}
+	return &mbr, issues, nil
+}
+
+// Funcion para encontrar particiones fuera del disco o que se traslapan entre sí
+func checkPartitions(mbr *Structs.MRB, repair bool) ([]Errors.FsckIssue, bool) {
+	var issues []Errors.FsckIssue
+	changed := false
+	header := int32(binary.Size(*mbr))
+
+	var used []int
+	for i := 0; i < 4; i++ {
+		part := &mbr.Partitions[i]
+		if part.Size == 0 {
+			continue
+		}
+		name := strings.TrimRight(string(part.Name[:]), "\x00")
+
+		if part.Size < 0 || part.Start < header || part.Start >= mbr.MbrSize {
+			issues = append(issues, Errors.FsckIssue{
+				Check:    Errors.CheckPartitions,
+				Message:  fmt.Sprintf("La partición '%s' (inicio %d, tamaño %d) está fuera del disco", name, part.Start, part.Size),
+				Repaired: repair,
+			})
+			if repair {
+				*part = Structs.Partition{}
+				changed = true
+			}
+			continue
+		}
+		if part.Start+part.Size > mbr.MbrSize {
+			issues = append(issues, Errors.FsckIssue{
+				Check:    Errors.CheckPartitions,
+				Message:  fmt.Sprintf("La partición '%s' termina en %d, después del final del disco (%d)", name, part.Start+part.Size, mbr.MbrSize),
+				Repaired: repair,
+			})
+			if repair {
+				part.Size = mbr.MbrSize - part.Start
+				changed = true
+			}
+		}
+		used = append(used, i)
+	}
+
+	// Las particiones se ordenan por inicio, cada una debe terminar antes de que empiece la siguiente
+	sort.Slice(used, func(a, b int) bool {
+		return mbr.Partitions[used[a]].Start < mbr.Partitions[used[b]].Start
+	})
+	for k := 0; k+1 < len(used); k++ {
+		current, next := &mbr.Partitions[used[k]], &mbr.Partitions[used[k+1]]
+		if current.Start+current.Size > next.Start {
+			currentName := strings.TrimRight(string(current.Name[:]), "\x00")
+			nextName := strings.TrimRight(string(next.Name[:]), "\x00")
+			issues = append(issues, Errors.FsckIssue{
+				Check:    Errors.CheckPartitions,
+				Message:  fmt.Sprintf("Las particiones '%s' y '%s' se traslapan, '%s' termina en %d y '%s' empieza en %d", currentName, nextName, currentName, current.Start+current.Size, nextName, next.Start),
+				Repaired: repair,
+			})
+			if repair {
+				current.Size = next.Start - current.Start
+				changed = true
+			}
+		}
+	}
+	return issues, changed
+}
+
+// Funcion para recorrer la cadena de EBRs hasta el primer EBR dañado
+// Cada EBR debe estar dentro de la extendida, tener un checksum válido y su partición lógica no debe
+// salirse de la extendida ni traslaparse con el siguiente EBR
+// Un EBR con la estructura válida y solo el checksum incorrecto (por ejemplo de un disco creado antes de los
+// checksums) se repara recalculándolo, sin cortar la cadena
+// Después de un EBR dañado no se puede confiar en su puntero al siguiente, por eso se reporta un solo EBR dañado por cadena
+func checkEBRChain(file Utilities.BlockDevice, extended Structs.Partition, repair bool) ([]Errors.FsckIssue, error) {
+	ebrSize := int32(binary.Size(Structs.EBR{}))
+	extendedEnd := extended.Start + extended.Size
+	extendedName := strings.TrimRight(string(extended.Name[:]), "\x00")
+	visited := make(map[int32]bool)
+
+	var issues []Errors.FsckIssue
+	prevPos := int32(-1)
+	var prev Structs.EBR
+	ebrPos := extended.Start
+	for ebrPos != -1 {
+		problem := ""
+		var ebr Structs.EBR
+		if ebrPos < extended.Start || ebrPos+ebrSize > extendedEnd {
+			problem = fmt.Sprintf("El EBR en la posición %d está fuera de la partición extendida '%s'", ebrPos, extendedName)
+		} else if visited[ebrPos] {
+			problem = fmt.Sprintf("La cadena de EBRs de '%s' forma un ciclo en la posición %d", extendedName, ebrPos)
+		} else if err := Utilities.ReadObject(file, &ebr, int64(ebrPos)); err != nil {
+			return issues, ioError(err, "No se pudo leer el EBR en la posición %d", ebrPos)
+		} else if ebr.PartSize < 0 || (ebr.PartSize > 0 && (ebr.PartStart < ebrPos+ebrSize || ebr.PartStart+ebr.PartSize > extendedEnd)) {
+			problem = fmt.Sprintf("La partición lógica '%s' (inicio %d, tamaño %d) está fuera de la partición extendida '%s'",
+				strings.TrimRight(string(ebr.PartName[:]), "\x00"), ebr.PartStart, ebr.PartSize, extendedName)
+		} else if ebr.PartNext != -1 && ebr.PartNext < ebr.PartStart+ebr.PartSize {
+			problem = fmt.Sprintf("El siguiente EBR (%d) se traslapa con la partición lógica '%s'",
+				ebr.PartNext, strings.TrimRight(string(ebr.PartName[:]), "\x00"))
+		}
+
+		if problem == "" && ebr.Checksum != Utilities.Checksum(ebr) {
+			issues = append(issues, Errors.FsckIssue{
+				Check:    Errors.CheckEBRChain,
+				Message:  fmt.Sprintf("El checksum del EBR en la posición %d no coincide", ebrPos),
+				Repaired: repair,
+			})
+			if repair {
+				if err := writeEBR(file, ebr, ebrPos); err != nil {
+					return issues, err
+				}
+			}
+		}
+		if problem == "" {
+			visited[ebrPos] = true
+			prev, prevPos = ebr, ebrPos
+			ebrPos = ebr.PartNext
+			continue
+		}
+
+		// La cadena se corta antes del EBR dañado, las particiones lógicas siguientes se pierden
+		issue := Errors.FsckIssue{Check: Errors.CheckEBRChain, Message: problem + ", la cadena se corta en este punto", Repaired: repair}
+		if repair {
+			if prevPos == -1 {
+				// El primer EBR está dañado, se reinicia la extendida con un EBR vacío
+				prevPos = extended.Start
+				prev = Structs.EBR{PartFit: extended.Fit[0], PartStart: extended.Start, PartSize: 0}
+			}
+			prev.PartNext = -1
+			if err := writeEBR(file, prev, prevPos); err != nil {
+				return append(issues, issue), err
+			}
+		}
+		return append(issues, issue), nil
+	}
+	return issues, nil
+}
@@ -0,0 +1,231 @@
+package DiskManagement
+
+import (
+	"encoding/binary"
+	"errors"
+	"path/filepath"
+	"proyecto1/Errors"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+	"strings"
+	"testing"
+)
+
+// Funcion para crear una partición primaria con nombre para las pruebas
+func testPartition(name string, start int32, size int32) Structs.Partition {
+	partition := Structs.Partition{Start: start, Size: size}
+	partition.Type[0] = 'p'
+	copy(partition.Name[:], name)
+	return partition
+}
+
+func TestCheckPartitions(t *testing.T) {
+	header := int32(binary.Size(Structs.MRB{}))
+	tests := []struct {
+		name       string
+		partitions []Structs.Partition
+		issues     int
+		// Inicio y tamaño esperados de cada partición después de reparar, una eliminada queda en 0
+		repaired [][2]int32
+	}{
+		{
+			name:       "sin problemas",
+			partitions: []Structs.Partition{testPartition("a", header, 1000), testPartition("b", header+1000, 1000)},
+			issues:     0,
+			repaired:   [][2]int32{{header, 1000}, {header + 1000, 1000}},
+		},
+		{
+			name:       "se traslapan",
+			partitions: []Structs.Partition{testPartition("a", header, 1500), testPartition("b", header+1000, 1000)},
+			issues:     1,
+			repaired:   [][2]int32{{header, 1000}, {header + 1000, 1000}},
+		},
+		{
+			name:       "termina después del disco",
+			partitions: []Structs.Partition{testPartition("a", 9000, 2000)},
+			issues:     1,
+			repaired:   [][2]int32{{9000, 1000}},
+		},
+		{
+			name:       "empieza fuera del disco",
+			partitions: []Structs.Partition{testPartition("a", header, 1000), testPartition("b", 20000, 1000)},
+			issues:     1,
+			repaired:   [][2]int32{{header, 1000}, {0, 0}},
+		},
+		{
+			name:       "empieza sobre el MBR",
+			partitions: []Structs.Partition{testPartition("a", 0, 1000)},
+			issues:     1,
+			repaired:   [][2]int32{{0, 0}},
+		},
+	}
+
+	for _, test := range tests {
+		for _, repair := range []bool{false, true} {
+			mbr := Structs.MRB{MbrSize: 10000}
+			copy(mbr.Partitions[:], test.partitions)
+
+			issues, changed := checkPartitions(&mbr, repair)
+			if len(issues) != test.issues {
+				t.Errorf("%s: se esperaban %d problema(s) y se obtuvieron %v", test.name, test.issues, issues)
+			}
+			for _, issue := range issues {
+				if issue.Check != Errors.CheckPartitions || issue.Repaired != repair {
+					t.Errorf("%s: problema inesperado %+v", test.name, issue)
+				}
+			}
+			if changed != (repair && test.issues > 0) {
+				t.Errorf("%s: repair=%v indicó cambios=%v", test.name, repair, changed)
+			}
+
+			for i, expected := range test.repaired {
+				part := mbr.Partitions[i]
+				if !repair {
+					expected = [2]int32{test.partitions[i].Start, test.partitions[i].Size}
+				}
+				if part.Start != expected[0] || part.Size != expected[1] {
+					t.Errorf("%s: repair=%v, la partición %d quedó en inicio %d y tamaño %d, se esperaba %v", test.name, repair, i, part.Start, part.Size, expected)
+				}
+			}
+		}
+	}
+}
+
+func TestCheckEBRChain(t *testing.T) {
+	ebrSize := int32(binary.Size(Structs.EBR{}))
+	extended := Structs.Partition{Start: 1000, Size: 4000}
+	extended.Type[0] = 'e'
+	extended.Fit[0] = 'f'
+	first := Structs.EBR{PartFit: 'f', PartStart: 1000 + ebrSize, PartSize: 500, PartNext: 2000}
+	second := Structs.EBR{PartFit: 'f', PartStart: 2000 + ebrSize, PartSize: 500, PartNext: -1}
+
+	tests := []struct {
+		name string
+		// Posición del EBR que se modifica sin actualizar su checksum y el cambio que se le hace
+		damaged int32
+		damage  func(ebr *Structs.EBR)
+		// Cadena que debe quedar después de reparar
+		chain []Structs.EBR
+	}{
+		{
+			// Como en un disco creado antes de los checksums, solo se recalcula y la cadena se conserva
+			name:    "solo el checksum",
+			damaged: 2000,
+			damage:  func(ebr *Structs.EBR) { ebr.PartSize++ },
+			chain:   []Structs.EBR{first, {PartFit: 'f', PartStart: 2000 + ebrSize, PartSize: 501, PartNext: -1}},
+		},
+		{
+			name:    "segundo EBR dañado",
+			damaged: 2000,
+			damage:  func(ebr *Structs.EBR) { ebr.PartNext = ebr.PartStart },
+			chain:   []Structs.EBR{{PartFit: 'f', PartStart: 1000 + ebrSize, PartSize: 500, PartNext: -1}},
+		},
+		{
+			name:    "primer EBR dañado",
+			damaged: 1000,
+			damage:  func(ebr *Structs.EBR) { ebr.PartSize = -1 },
+			chain:   []Structs.EBR{{PartFit: 'f', PartStart: 1000, PartSize: 0, PartNext: -1}},
+		},
+	}
+
+	for _, test := range tests {
+		file := Utilities.NewMemoryDevice(10000)
+		if err := writeEBR(file, first, 1000); err != nil {
+			t.Fatal(err)
+		}
+		if err := writeEBR(file, second, 2000); err != nil {
+			t.Fatal(err)
+		}
+
+		var ebr Structs.EBR
+		if err := Utilities.ReadObject(file, &ebr, int64(test.damaged)); err != nil {
+			t.Fatal(err)
+		}
+		test.damage(&ebr)
+		if err := Utilities.WriteObject(file, ebr, int64(test.damaged)); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := readEBRChain(file, extended); !errors.Is(err, Errors.ErrCorrupt) {
+			t.Fatalf("%s: se esperaba un error de EBR corrupto y se obtuvo %v", test.name, err)
+		}
+
+		issues, err := checkEBRChain(file, extended, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(issues) != 1 || issues[0].Check != Errors.CheckEBRChain || !issues[0].Repaired {
+			t.Fatalf("%s: se esperaba un problema reparado en la cadena de EBRs y se obtuvo %+v", test.name, issues)
+		}
+
+		chain, _, err := readEBRChain(file, extended)
+		if err != nil {
+			t.Fatalf("%s: después de reparar la cadena no se puede leer: %v", test.name, err)
+		}
+		for i := range test.chain {
+			test.chain[i].Checksum = Utilities.Checksum(test.chain[i])
+		}
+		if len(chain) != len(test.chain) {
+			t.Fatalf("%s: la cadena quedó %+v, se esperaba %+v", test.name, chain, test.chain)
+		}
+		for i := range chain {
+			if chain[i] != test.chain[i] {
+				t.Errorf("%s: el EBR %d quedó %+v, se esperaba %+v", test.name, i, chain[i], test.chain[i])
+			}
+		}
+		if issues, err := checkEBRChain(file, extended, false); err != nil || len(issues) != 0 {
+			t.Errorf("%s: después de reparar quedaron problemas %+v (%v)", test.name, issues, err)
+		}
+	}
+}
+
+func TestCheckDiskRepairsChecksums(t *testing.T) {
+	Utilities.SetMemoryMode(true)
+	t.Cleanup(func() { Utilities.SetMemoryMode(false) })
+
+	// Un disco creado antes de que el MBR y los EBRs tuvieran checksum, con una lógica dentro de la extendida
+	path := filepath.Join(t.TempDir(), "viejo.mia")
+	if err := Utilities.CreateFile(path); err != nil {
+		t.Fatal(err)
+	}
+	file, err := Utilities.OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err := file.Truncate(10000); err != nil {
+		t.Fatal(err)
+	}
+	ebrSize := int32(binary.Size(Structs.EBR{}))
+	mbr := Structs.MRB{MbrSize: 10000}
+	mbr.Partitions[0] = testPartition("ext", 1000, 4000)
+	mbr.Partitions[0].Type[0] = 'e'
+	logical := Structs.EBR{PartFit: 'f', PartStart: 1000 + ebrSize, PartSize: 500, PartNext: -1}
+	copy(logical.PartName[:], "l1")
+	if err := Utilities.WriteObject(file, mbr, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := Utilities.WriteObject(file, logical, 1000); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := ReadDisk(path); !errors.Is(err, Errors.ErrCorrupt) || !strings.Contains(err.Error(), "fsck -path=<disco> -repair") {
+		t.Fatalf("Se esperaba un error de checksum que indique fsck -repair y se obtuvo %v", err)
+	}
+
+	issues, _, err := CheckDisk(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 2 || issues[0].Check != Errors.CheckMBR || issues[1].Check != Errors.CheckEBRChain {
+		t.Fatalf("Se esperaban los checksums del MBR y del EBR y se obtuvo %+v", issues)
+	}
+
+	// Después de reparar el disco se lee sin perder la partición lógica
+	_, ebrs, err := ReadDisk(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ebrs) != 1 || layoutString(ebrs[0].PartName[:]) != "l1" {
+		t.Errorf("Se esperaba la lógica l1 y se obtuvo %+v", ebrs)
+	}
+}
@@ -0,0 +1,247 @@
+package DiskManagement
+
+import (
+	"encoding/binary"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+	"strings"
+)
+
+// Distribución de un disco (MBR, sus cuatro particiones y la cadena de EBRs) para dumplayout y restorelayout
+type Layout struct {
+	Size         int32              `json:"size"`
+	CreationDate string             `json:"creation_date"`
+	Signature    int32              `json:"signature"`
+	Fit          string             `json:"fit"`
+	Partitions   [4]LayoutPartition `json:"partitions"`
+	EBRs         []LayoutEBR        `json:"ebrs"`
+}
+
+type LayoutPartition struct {
+	Status      string `json:"status"`
+	Type        string `json:"type"`
+	Fit         string `json:"fit"`
+	Start       int32  `json:"start"`
+	Size        int32  `json:"size"`
+	Name        string `json:"name"`
+	Correlative int32  `json:"correlative"`
+	Id          string `json:"id"`
+}
+
+// Position es la posición del EBR en el disco, el primero siempre está al inicio de la extendida
+type LayoutEBR struct {
+	Position int32  `json:"position"`
+	Mount    string `json:"mount"`
+	Fit      string `json:"fit"`
+	Start    int32  `json:"start"`
+	Size     int32  `json:"size"`
+	Next     int32  `json:"next"`
+	Name     string `json:"name"`
+}
+
+// Funcion para obtener la distribución de particiones de un disco
+func DumpLayout(path string) (Layout, error) {
+	file, err := OpenDisk(path)
+	if err != nil {
+		return Layout{}, err
+	}
+	defer file.Close()
+	return dumpLayout(file)
+}
+
+// Funcion para leer la distribución de particiones de un dispositivo ya abierto
+func dumpLayout(file Utilities.BlockDevice) (Layout, error) {
+	TempMBR, err := readMBR(file)
+	if err != nil {
+		return Layout{}, err
+	}
+
+	layout := Layout{
+		Size:         TempMBR.MbrSize,
+		CreationDate: layoutString(TempMBR.CreationDate[:]),
+		Signature:    TempMBR.Signature,
+		Fit:          layoutString(TempMBR.Fit[:]),
+		EBRs:         []LayoutEBR{},
+	}
+	for i, part := range TempMBR.Partitions {
+		layout.Partitions[i] = LayoutPartition{
+			Status:      layoutString(part.Status[:]),
+			Type:        layoutString(part.Type[:]),
+			Fit:         layoutString(part.Fit[:]),
+			Start:       part.Start,
+			Size:        part.Size,
+			Name:        layoutString(part.Name[:]),
+			Correlative: part.Correlative,
+			Id:          layoutString(part.Id[:]),
+		}
+
+		if part.Size > 0 && part.Type[0] == 'e' {
+			chain, positions, err := readEBRChain(file, part)
+			if err != nil {
+				return Layout{}, err
+			}
+			for j, ebr := range chain {
+				layout.EBRs = append(layout.EBRs, LayoutEBR{
+					Position: positions[j],
+					Mount:    layoutString([]byte{ebr.PartMount}),
+					Fit:      layoutString([]byte{ebr.PartFit}),
+					Start:    ebr.PartStart,
+					Size:     ebr.PartSize,
+					Next:     ebr.PartNext,
+					Name:     layoutString(ebr.PartName[:]),
+				})
+			}
+		}
+	}
+	return layout, nil
+}
+
+// Funcion para escribir una distribución de particiones en un disco nuevo o existente
+// Si el disco no existe se crea con el tamaño de la distribución, si existe debe ser al menos de ese tamaño
+// Las particiones se restauran desmontadas, los IDs de montaje solo son válidos en la sesión en que se crearon
+func RestoreLayout(path string, layout Layout) error {
+	TempMBR, ebrs, err := layoutStructures(layout)
+	if err != nil {
+		return err
+	}
+	if err := validateLayout(TempMBR, layout.EBRs); err != nil {
+		return err
+	}
+
+	// CreateFile no modifica un disco existente
+	if err := Utilities.CreateFile(path); err != nil {
+		return ioError(err, "No se pudo crear el archivo en la ruta: %s", path)
+	}
+	file, err := OpenDisk(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	size, err := file.Size()
+	if err != nil {
+		return ioError(err, "No se pudo obtener el tamaño del disco")
+	}
+	if size == 0 {
+		if err := file.Truncate(int64(layout.Size)); err != nil {
+			return ioError(err, "No se pudo asignar el tamaño del disco")
+		}
+	} else if size < int64(layout.Size) {
+		return noSpaceError("La distribución requiere un disco de %d bytes pero el disco tiene %d bytes", layout.Size, size)
+	}
+
+	if err := writeMBR(file, TempMBR); err != nil {
+		return err
+	}
+	for i, ebr := range ebrs {
+		if err := writeEBR(file, ebr, layout.EBRs[i].Position); err != nil {
+			return err
+		}
+	}
+	if err := file.Sync(); err != nil {
+		return ioError(err, "No se pudo guardar el disco")
+	}
+
+	Structs.PrintMBR(TempMBR)
+	return nil
+}
+
+// Funcion para convertir la distribución a las estructuras que se escriben en el disco
+func layoutStructures(layout Layout) (Structs.MRB, []Structs.EBR, error) {
+	var TempMBR Structs.MRB
+	if layout.Size <= int32(binary.Size(TempMBR)) {
+		return TempMBR, nil, invalidError("El tamaño del disco en la distribución debe ser mayor a %d bytes", binary.Size(TempMBR))
+	}
+	// El MBR guarda solo la primera letra del fit
+	if layout.Fit == "" || !strings.Contains("bfw", layout.Fit[:1]) {
+		return TempMBR, nil, invalidError("Fit '%s' inválido en la distribución", layout.Fit)
+	}
+	TempMBR.MbrSize = layout.Size
+	TempMBR.Signature = layout.Signature
+	copy(TempMBR.CreationDate[:], layout.CreationDate)
+	copy(TempMBR.Fit[:], layout.Fit)
+
+	for i, part := range layout.Partitions {
+		if part.Size == 0 {
+			continue
+		}
+		if part.Type != "p" && part.Type != "e" {
+			return TempMBR, nil, invalidError("La partición '%s' tiene un tipo inválido '%s', debe ser 'p' o 'e'", part.Name, part.Type)
+		}
+		if len(part.Name) > len(TempMBR.Partitions[i].Name) {
+			return TempMBR, nil, invalidError("El nombre de la partición '%s' es demasiado largo", part.Name)
+		}
+		TempMBR.Partitions[i] = Structs.Partition{Start: part.Start, Size: part.Size, Correlative: part.Correlative}
+		copy(TempMBR.Partitions[i].Status[:], "0")
+		copy(TempMBR.Partitions[i].Type[:], part.Type)
+		copy(TempMBR.Partitions[i].Fit[:], part.Fit)
+		copy(TempMBR.Partitions[i].Name[:], part.Name)
+	}
+
+	var ebrs []Structs.EBR
+	for _, entry := range layout.EBRs {
+		ebr := Structs.EBR{PartStart: entry.Start, PartSize: entry.Size, PartNext: entry.Next}
+		if entry.Fit != "" {
+			ebr.PartFit = entry.Fit[0]
+		}
+		copy(ebr.PartName[:], entry.Name)
+		ebrs = append(ebrs, ebr)
+	}
+	return TempMBR, ebrs, nil
+}
+
+// Funcion para validar que las particiones quepan en el disco sin traslaparse y que los EBRs formen
+// una cadena válida dentro de la partición extendida
+func validateLayout(mbr Structs.MRB, ebrs []LayoutEBR) error {
+	if issues, _ := checkPartitions(&mbr, false); len(issues) > 0 {
+		return invalidError("Distribución inválida: %s", issues[0].Message)
+	}
+
+	var extended *Structs.Partition
+	for i := range mbr.Partitions {
+		if mbr.Partitions[i].Size > 0 && mbr.Partitions[i].Type[0] == 'e' {
+			if extended != nil {
+				return invalidError("Distribución inválida: solo se permite una partición extendida por disco")
+			}
+			extended = &mbr.Partitions[i]
+		}
+	}
+	if extended == nil {
+		if len(ebrs) > 0 {
+			return invalidError("Distribución inválida: hay EBRs pero no hay una partición extendida")
+		}
+		return nil
+	}
+	if len(ebrs) == 0 {
+		return invalidError("Distribución inválida: la partición extendida no tiene EBR inicial")
+	}
+
+	ebrSize := int32(binary.Size(Structs.EBR{}))
+	extendedEnd := extended.Start + extended.Size
+	expected := extended.Start
+	for i, ebr := range ebrs {
+		if ebr.Position != expected {
+			return invalidError("Distribución inválida: el EBR %d está en la posición %d pero la cadena apunta a %d", i+1, ebr.Position, expected)
+		}
+		if ebr.Position+ebrSize > extendedEnd {
+			return invalidError("Distribución inválida: el EBR en la posición %d está fuera de la partición extendida", ebr.Position)
+		}
+		if ebr.Size < 0 || (ebr.Size > 0 && (ebr.Start < ebr.Position+ebrSize || ebr.Start+ebr.Size > extendedEnd)) {
+			return invalidError("Distribución inválida: la partición lógica '%s' está fuera de la partición extendida", ebr.Name)
+		}
+		last := i == len(ebrs)-1
+		if last && ebr.Next != -1 {
+			return invalidError("Distribución inválida: el último EBR debe tener next = -1")
+		}
+		if !last && ebr.Next < ebr.Start+ebr.Size {
+			return invalidError("Distribución inválida: el EBR en la posición %d se traslapa con la partición lógica '%s'", ebr.Next, ebr.Name)
+		}
+		expected = ebr.Next
+	}
+	return nil
+}
+
+// Funcion para convertir un arreglo de bytes de una estructura a texto sin caracteres nulos
+func layoutString(data []byte) string {
+	return strings.TrimRight(string(data), "\x00")
+}
@@ -0,0 +1,174 @@
+package DiskManagement
+
+import (
+	"log"
+	"math/rand"
+	"path/filepath"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+	"regexp"
+)
+
+// Los tags de los snapshots se usan como nombre de archivo
+var snapshotTagRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Funcion para copiar un disco a una nueva ruta con una nueva Signature en el MBR
+// Las particiones de la copia quedan desmontadas
+func CloneDisk(path string, dest string) error {
+	if Utilities.FileExists(dest) {
+		return withCode("disk_exists", invalidError("Ya existe un archivo en la ruta destino: %s", dest))
+	}
+
+	TempMBR, err := copyDisk(path, dest)
+	if err != nil {
+		return err
+	}
+
+	file, err := OpenDisk(dest)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// La Signature identifica al disco, la copia necesita una distinta a la del original
+	signature := TempMBR.Signature
+	for signature == TempMBR.Signature {
+		signature = rand.Int31()
+	}
+	TempMBR.Signature = signature
+	unmountPartitions(&TempMBR)
+
+	if err := writeMBR(file, TempMBR); err != nil {
+		return err
+	}
+	Structs.PrintMBR(TempMBR)
+	return nil
+}
+
+// Funcion para guardar una copia del disco con el tag dado en la carpeta de snapshots del disco
+func Snapshot(path string, tag string) error {
+	snapshotPath, err := snapshotPath(path, tag)
+	if err != nil {
+		return err
+	}
+	if Utilities.FileExists(snapshotPath) {
+		return withCode("snapshot_exists", invalidError("Ya existe un snapshot con el tag '%s' para el disco %s", tag, path))
+	}
+
+	if _, err := copyDisk(path, snapshotPath); err != nil {
+		return err
+	}
+	log.Printf("Snapshot '%s' guardado en %s\n", tag, snapshotPath)
+	return nil
+}
+
+// Funcion para reemplazar el contenido del disco con el snapshot del tag dado
+// Las particiones montadas del disco siguen montadas si existen en el snapshot, las demás se desmontan
+func Restore(path string, tag string) error {
+	snapshotPath, err := snapshotPath(path, tag)
+	if err != nil {
+		return err
+	}
+	if !Utilities.FileExists(snapshotPath) {
+		return withCode("snapshot_not_found", notFoundError("No existe un snapshot con el tag '%s' para el disco %s", tag, path))
+	}
+	if !Utilities.FileExists(path) {
+		return withCode("disk_not_found", notFoundError("No existe un disco en la ruta: %s", path))
+	}
+
+	TempMBR, err := copyDisk(snapshotPath, path)
+	if err != nil {
+		return err
+	}
+
+	file, err := OpenDisk(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// El estado de montaje del snapshot puede no coincidir con el de la sesión actual
+	unmountPartitions(&TempMBR)
+	diskID := generateDiskID(path)
+	var stillMounted []MountedPartition
+	for _, mounted := range mountedPartitions[diskID] {
+		remounted := false
+		for i := range TempMBR.Partitions {
+			if TempMBR.Partitions[i].Size > 0 && layoutString(TempMBR.Partitions[i].Name[:]) == mounted.Name {
+				TempMBR.Partitions[i].Status[0] = '1'
+				copy(TempMBR.Partitions[i].Id[:], mounted.ID)
+				remounted = true
+			}
+		}
+		if remounted {
+			stillMounted = append(stillMounted, mounted)
+		} else {
+			log.Printf("La partición %s ya no existe después de restaurar, se desmonta\n", mounted.ID)
+		}
+	}
+	if len(stillMounted) == 0 {
+		delete(mountedPartitions, diskID)
+	} else {
+		mountedPartitions[diskID] = stillMounted
+	}
+
+	if err := writeMBR(file, TempMBR); err != nil {
+		return err
+	}
+	Structs.PrintMBR(TempMBR)
+	return nil
+}
+
+// Funcion para obtener la ruta del snapshot, se guarda en la carpeta <disco>.snapshots junto al disco
+func snapshotPath(path string, tag string) (string, error) {
+	if !snapshotTagRe.MatchString(tag) {
+		return "", invalidError("El tag '%s' solo puede contener letras, números, '-' y '_'", tag)
+	}
+	return filepath.Join(path+".snapshots", tag+".mia"), nil
+}
+
+// Funcion para copiar el contenido completo de un disco válido a otra ruta, crea o reemplaza el destino
+func copyDisk(path string, dest string) (Structs.MRB, error) {
+	src, err := OpenDisk(path)
+	if err != nil {
+		return Structs.MRB{}, err
+	}
+	defer src.Close()
+
+	// Solo se copian discos con un MBR válido
+	TempMBR, err := readMBR(src)
+	if err != nil {
+		return TempMBR, err
+	}
+	size, err := src.Size()
+	if err != nil {
+		return TempMBR, ioError(err, "No se pudo obtener el tamaño del disco")
+	}
+
+	if err := Utilities.CreateFile(dest); err != nil {
+		return TempMBR, ioError(err, "No se pudo crear el archivo en la ruta: %s", dest)
+	}
+	dst, err := OpenDisk(dest)
+	if err != nil {
+		return TempMBR, err
+	}
+	defer dst.Close()
+
+	if err := dst.Truncate(size); err != nil {
+		return TempMBR, ioError(err, "No se pudo asignar el tamaño de %s", dest)
+	}
+	if err := Utilities.CopyDevice(dst, src, size); err != nil {
+		return TempMBR, ioError(err, "No se pudo copiar %s a %s", path, dest)
+	}
+	return TempMBR, nil
+}
+
+// Funcion para marcar como desmontadas las particiones de un MBR
+func unmountPartitions(mbr *Structs.MRB) {
+	for i := 0; i < 4; i++ {
+		if mbr.Partitions[i].Size != 0 {
+			mbr.Partitions[i].Status[0] = '0'
+			mbr.Partitions[i].Id = [4]byte{}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package DiskManagement
+
+import (
+	"errors"
+	"log"
+	"proyecto1/Errors"
+	"proyecto1/Utilities"
+)
+
+// Funcion para deshacer los últimos steps comandos que escribieron en un disco
+// Solo se pueden deshacer los comandos ejecutados en la sesión actual después de la última escritura que no se registró
+// (mkdisk, restore, restorelayout, WebDAV o la API de archivos). Devuelve los comandos que se deshicieron, del más reciente al más antiguo
+func Undo(path string, steps int) ([]Utilities.UndoEntry, error) {
+	if steps <= 0 {
+		return nil, invalidError("Steps debe ser mayor a 0")
+	}
+	if !Utilities.FileExists(path) {
+		return nil, withCode("disk_not_found", notFoundError("No existe un disco en la ruta: %s", path))
+	}
+
+	entries := Utilities.UndoEntries(path)
+	if len(entries) == 0 {
+		return nil, withCode("nothing_to_undo", notFoundError("No hay comandos para deshacer en el disco %s", path))
+	}
+	if steps > len(entries) {
+		return nil, invalidError("Solo se pueden deshacer %d comando(s) en el disco %s", len(entries), path)
+	}
+
+	undone, err := Utilities.ApplyUndo(path, steps)
+	if len(undone) > 0 {
+		if err := syncMounts(path); err != nil {
+			return undone, err
+		}
+	}
+	if errors.Is(err, Utilities.ErrUndoConflict) {
+		return undone, &Errors.DiskError{Kind: Errors.ErrInvalid, Code: "undo_conflict", Message: "No se pudo deshacer el comando, el disco se modificó fuera del registro", Err: err}
+	}
+	if err != nil {
+		return undone, ioError(err, "No se pudo deshacer el comando en el disco %s", path)
+	}
+	return undone, nil
+}
+
+// Funcion para desmontar de la sesión las particiones de un disco que ya no están montadas en su MBR
+// Por ejemplo después de deshacer un mount o el fdisk que creó la partición
+func syncMounts(path string) error {
+	file, err := OpenDisk(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	TempMBR, err := readMBR(file)
+	if err != nil {
+		return err
+	}
+
+	diskID := generateDiskID(path)
+	var stillMounted []MountedPartition
+	for _, mounted := range mountedPartitions[diskID] {
+		found := false
+		for _, partition := range TempMBR.Partitions {
+			if partition.Size > 0 && partition.Status[0] == '1' && layoutString(partition.Name[:]) == mounted.Name && layoutString(partition.Id[:]) == mounted.ID {
+				found = true
+			}
+		}
+		if found {
+			stillMounted = append(stillMounted, mounted)
+		} else {
+			log.Printf("La partición %s ya no está montada después de deshacer, se desmonta\n", mounted.ID)
+		}
+	}
+	if len(stillMounted) == 0 {
+		delete(mountedPartitions, diskID)
+	} else {
+		mountedPartitions[diskID] = stillMounted
+	}
+	return nil
+}
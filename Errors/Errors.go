@@ -0,0 +1,42 @@
+// Tipos de error y problemas de fsck que comparten DiskManagement, FileSystem y Analyzer
+package Errors
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Tipos de error que devuelven las funciones de DiskManagement y FileSystem, se comparan con errors.Is
+var (
+	ErrInvalid  = errors.New("parámetro inválido")
+	ErrNotFound = errors.New("no encontrado")
+	ErrNoSpace  = errors.New("sin espacio")
+	ErrIO       = errors.New("error de lectura/escritura")
+	ErrCorrupt  = errors.New("estructura corrupta")
+	ErrDenied   = errors.New("permiso denegado")
+)
+
+// Error de una operación de disco, Kind es uno de los errores anteriores,
+// Code un código estable más específico (por ejemplo partition_not_found), vacío si basta con el tipo,
+// y Err el error original (por ejemplo el error del sistema operativo) si existe
+type DiskError struct {
+	Kind    error
+	Code    string
+	Message string
+	Err     error
+}
+
+func (e *DiskError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *DiskError) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *DiskError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,21 @@
+package Errors
+
+// Nombres de las verificaciones que realiza fsck, en el orden en que se reportan
+const (
+	CheckMBR        = "MBR"
+	CheckPartitions = "Particiones"
+	CheckEBRChain   = "Cadena de EBRs"
+	CheckSuperblock = "Superbloque"
+	CheckBitmaps    = "Bitmaps"
+	CheckCounters   = "Contadores del superbloque"
+	CheckOrphans    = "Inodos huérfanos"
+)
+
+var FsckChecks = []string{CheckMBR, CheckPartitions, CheckEBRChain, CheckSuperblock, CheckBitmaps, CheckCounters, CheckOrphans}
+
+// Problema encontrado por fsck, Repaired indica si se corrigió en modo -repair
+type FsckIssue struct {
+	Check    string `json:"check"`
+	Message  string `json:"message"`
+	Repaired bool   `json:"repaired"`
+}
@@ -0,0 +1,258 @@
+package FileSystem
+
+import (
+	"archive/tar"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"proyecto1/Errors"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+	"strings"
+	"time"
+)
+
+// Resumen de lo que se escribió con Export
+type ExportResult struct {
+	Directories int
+	Files       int
+	Bytes       int64
+}
+
+// Elemento del árbol de la partición, Path es relativo a la ruta exportada
+type exportEntry struct {
+	Path    string
+	Inode   Structs.Inode
+	Content []byte
+}
+
+// Funcion para escribir el contenido de una ruta de la partición en el sistema anfitrión
+// Si out termina en .tar se genera un archivo tar, si no se crea una carpeta con el mismo árbol
+// Las fechas y permisos de cada archivo se toman de su inodo
+func Export(file Utilities.BlockDevice, partition Structs.Partition, fsPath string, out string) (ExportResult, error) {
+	var result ExportResult
+
+	sb, err := ReadSuperblock(file, partition)
+	if err != nil {
+		return result, err
+	}
+	index, err := SearchInode(file, sb, fsPath)
+	if err != nil {
+		return result, err
+	}
+	inode, err := ReadInode(file, sb, index)
+	if err != nil {
+		return result, err
+	}
+
+	// Un archivo se exporta con su nombre, una carpeta exporta su contenido
+	root := "."
+	if inode.I_type[0] == '1' {
+		root = path.Base(fsPath)
+		if !safeExportName(root) {
+			return result, &Errors.DiskError{Kind: Errors.ErrInvalid, Message: fmt.Sprintf("Ruta inválida %s", fsPath)}
+		}
+	}
+
+	var entries []exportEntry
+	visited := make(map[int32]bool)
+	if err := collectEntries(file, sb, index, root, visited, &entries); err != nil {
+		return result, err
+	}
+
+	for _, entry := range entries {
+		if entry.Inode.I_type[0] == '0' {
+			if entry.Path != "." {
+				result.Directories++
+			}
+		} else {
+			result.Files++
+			result.Bytes += int64(len(entry.Content))
+		}
+	}
+
+	if strings.HasSuffix(strings.ToLower(out), ".tar") {
+		users, groups, _ := ReadUsersAndGroups(file, sb)
+		return result, exportTar(entries, out, users, groups)
+	}
+	return result, exportDirectory(entries, out)
+}
+
+// Funcion recursiva para obtener en orden las carpetas y archivos a partir de un inodo
+func collectEntries(file Utilities.BlockDevice, sb Structs.Superblock, index int32, rel string, visited map[int32]bool, entries *[]exportEntry) error {
+	if visited[index] {
+		return nil
+	}
+	visited[index] = true
+
+	inode, err := ReadInode(file, sb, index)
+	if err != nil {
+		return err
+	}
+
+	if inode.I_type[0] == '1' {
+		content, err := ReadFileBytes(file, sb, inode)
+		if err != nil {
+			return err
+		}
+		*entries = append(*entries, exportEntry{Path: rel, Inode: inode, Content: content})
+		return nil
+	}
+
+	*entries = append(*entries, exportEntry{Path: rel, Inode: inode})
+	children, err := ReadDirectory(file, sb, inode)
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		// Los nombres vienen de la imagen del disco, uno como "../x" escribiría fuera de la carpeta de salida
+		name := ContentName(child)
+		if !safeExportName(name) {
+			return &Errors.DiskError{Kind: Errors.ErrCorrupt, Message: fmt.Sprintf("La carpeta %s tiene una entrada con un nombre inválido %q", rel, name)}
+		}
+		if err := collectEntries(file, sb, child.B_inodo, path.Join(rel, name), visited, entries); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Funcion para escribir las entradas en una carpeta del sistema anfitrión
+func exportDirectory(entries []exportEntry, out string) error {
+	if err := os.MkdirAll(out, os.ModePerm); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		target, err := exportTarget(out, entry.Path)
+		if err != nil {
+			return err
+		}
+		if entry.Inode.I_type[0] == '0' {
+			if err := os.MkdirAll(target, os.ModePerm); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.WriteFile(target, entry.Content, 0644); err != nil {
+			return err
+		}
+		if err := os.Chmod(target, inodeMode(entry.Inode)); err != nil {
+			return err
+		}
+		modTime := inodeTime(entry.Inode)
+		if err := os.Chtimes(target, modTime, modTime); err != nil {
+			return err
+		}
+	}
+
+	// Los permisos y fechas de las carpetas se aplican al final, de la más profunda a la raíz,
+	// para que crear su contenido no las modifique
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if entry.Inode.I_type[0] != '0' {
+			continue
+		}
+		target, err := exportTarget(out, entry.Path)
+		if err != nil {
+			return err
+		}
+		if err := os.Chmod(target, inodeMode(entry.Inode)); err != nil {
+			return err
+		}
+		modTime := inodeTime(entry.Inode)
+		if err := os.Chtimes(target, modTime, modTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Funcion para escribir las entradas en un archivo tar
+func exportTar(entries []exportEntry, out string, users map[int32]string, groups map[int32]string) error {
+	if err := os.MkdirAll(filepath.Dir(out), os.ModePerm); err != nil {
+		return err
+	}
+	tarFile, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	defer tarFile.Close()
+
+	tw := tar.NewWriter(tarFile)
+	for _, entry := range entries {
+		if _, err := exportTarget(".", entry.Path); err != nil {
+			return err
+		}
+		header := &tar.Header{
+			Name:    entry.Path,
+			Mode:    int64(inodeMode(entry.Inode).Perm()),
+			ModTime: inodeTime(entry.Inode),
+			Uid:     int(entry.Inode.I_uid),
+			Gid:     int(entry.Inode.I_gid),
+			Uname:   users[entry.Inode.I_uid],
+			Gname:   groups[entry.Inode.I_gid],
+		}
+		if entry.Inode.I_type[0] == '0' {
+			header.Typeflag = tar.TypeDir
+			header.Name += "/"
+		} else {
+			header.Typeflag = tar.TypeReg
+			header.Size = int64(len(entry.Content))
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("No se pudo escribir %s en el tar: %v", entry.Path, err)
+		}
+		if _, err := tw.Write(entry.Content); err != nil {
+			return fmt.Errorf("No se pudo escribir %s en el tar: %v", entry.Path, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return tarFile.Close()
+}
+
+// Funcion para saber si un nombre de una entrada se puede usar como nombre de archivo en el sistema anfitrión
+func safeExportName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, "/\\")
+}
+
+// Funcion para obtener la ruta en el sistema anfitrión de una entrada, verificando que quede dentro de out
+func exportTarget(out string, rel string) (string, error) {
+	target := filepath.Join(out, filepath.FromSlash(rel))
+	inside, err := filepath.Rel(out, target)
+	if err != nil || inside == ".." || strings.HasPrefix(inside, ".."+string(filepath.Separator)) || filepath.IsAbs(rel) {
+		return "", &Errors.DiskError{Kind: Errors.ErrCorrupt, Message: fmt.Sprintf("La entrada %s queda fuera de la carpeta de salida", rel)}
+	}
+	return target, nil
+}
+
+// Funcion para convertir los permisos UGO del inodo a permisos del sistema anfitrión
+// Las carpetas necesitan permiso de ejecución para poder abrirse, se agrega donde hay permiso de lectura
+func inodeMode(inode Structs.Inode) os.FileMode {
+	var mode os.FileMode
+	for _, digit := range inode.I_perm {
+		value := os.FileMode(0)
+		if digit >= '0' && digit <= '7' {
+			value = os.FileMode(digit - '0')
+		}
+		if inode.I_type[0] == '0' && value&4 != 0 {
+			value |= 1
+		}
+		mode = mode<<3 | value
+	}
+	return mode
+}
+
+// Funcion para obtener la fecha de modificación del inodo, si no se puede leer se usa la fecha actual
+func inodeTime(inode Structs.Inode) time.Time {
+	date := strings.TrimRight(string(inode.I_mtime[:]), "\x00")
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "02/01/2006 15:04", "2006-01-02"} {
+		if parsed, err := time.ParseInLocation(layout, date, time.Local); err == nil {
+			return parsed
+		}
+	}
+	return time.Now()
+}
@@ -0,0 +1,56 @@
+package FileSystem
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"proyecto1/Errors"
+	"proyecto1/Structs"
+	"testing"
+)
+
+func TestExportRejectsUnsafeNames(t *testing.T) {
+	for _, name := range []string{"../../x", "../x", "a/b", `a\b`} {
+		file, partition := newTestPartition(t, 64*1024, false)
+		writer, err := NewWriter(file, partition)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dir, err := writer.Mkdir(0, "d", RootUID, 1, "664")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.WriteFile(dir, "f.txt", []byte("hola"), RootUID, 1, "664"); err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		// Se cambia el nombre de la entrada directamente en el bloque de carpeta, como en una imagen manipulada
+		sb := writer.Superblock()
+		inode, err := ReadInode(file, sb, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var folder Structs.Folderblock
+		if err := ReadBlock(file, sb, inode.I_block[0], &folder); err != nil {
+			t.Fatal(err)
+		}
+		folder.B_content[2].B_name = [12]byte{}
+		copy(folder.B_content[2].B_name[:], name)
+		if err := writer.writeBlock(inode.I_block[0], folder); err != nil {
+			t.Fatal(err)
+		}
+
+		base := t.TempDir()
+		for _, out := range []string{filepath.Join(base, "out", "a"), filepath.Join(base, "out.tar")} {
+			if _, err := Export(file, partition, "/", out); !errors.Is(err, Errors.ErrCorrupt) {
+				t.Errorf("Export de %q a %s: se esperaba un error de estructura corrupta y se obtuvo %v", name, out, err)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(base, "x")); err == nil {
+			t.Errorf("Export de %q escribió fuera de la carpeta de salida", name)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package FileSystem
+
+import (
+	"os"
+	"path"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+	"time"
+)
+
+// Información de un archivo o carpeta de la partición, implementa os.FileInfo
+type FileInfo struct {
+	name  string
+	index int32
+	inode Structs.Inode
+}
+
+func (f FileInfo) Name() string {
+	return f.name
+}
+
+// Las carpetas tienen tamaño 0, los archivos el tamaño guardado en el inodo
+func (f FileInfo) Size() int64 {
+	if f.IsDir() {
+		return 0
+	}
+	return int64(f.inode.I_size)
+}
+
+func (f FileInfo) Mode() os.FileMode {
+	mode := inodeMode(f.inode)
+	if f.IsDir() {
+		mode |= os.ModeDir
+	}
+	return mode
+}
+
+func (f FileInfo) ModTime() time.Time {
+	return inodeTime(f.inode)
+}
+
+func (f FileInfo) IsDir() bool {
+	return f.inode.I_type[0] == '0'
+}
+
+// Sys devuelve el inodo
+func (f FileInfo) Sys() interface{} {
+	return f.inode
+}
+
+// Número de inodo del archivo o carpeta
+func (f FileInfo) Index() int32 {
+	return f.index
+}
+
+func (f FileInfo) Inode() Structs.Inode {
+	return f.inode
+}
+
+// Funcion para obtener la información de una ruta absoluta de la partición
+func Stat(file Utilities.BlockDevice, sb Structs.Superblock, fsPath string) (FileInfo, error) {
+	index, err := SearchInode(file, sb, fsPath)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	inode, err := ReadInode(file, sb, index)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	return FileInfo{name: path.Base(path.Clean("/" + fsPath)), index: index, inode: inode}, nil
+}
+
+// Funcion para obtener la información de las entradas de una carpeta, sin incluir "." y ".."
+func ReadDirInfo(file Utilities.BlockDevice, sb Structs.Superblock, dir Structs.Inode) ([]FileInfo, error) {
+	entries, err := ReadDirectory(file, sb, dir)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		inode, err := ReadInode(file, sb, entry.B_inodo)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, FileInfo{name: ContentName(entry), index: entry.B_inodo, inode: inode})
+	}
+	return infos, nil
+}
@@ -0,0 +1,315 @@
+package FileSystem
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"proyecto1/Errors"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+	"strconv"
+	"strings"
+)
+
+// Error que se devuelve al leer el superbloque de una partición sin formato
+var ErrNotFormatted = errors.New("La partición no ha sido formateada")
+
+// Funcion para leer el superbloque de una partición, verifica que la partición esté formateada
+func ReadSuperblock(file Utilities.BlockDevice, partition Structs.Partition) (Structs.Superblock, error) {
+	sb, err := readSuperblock(file, partition)
+	if err != nil {
+		return sb, err
+	}
+	if sb.S_checksum != Utilities.Checksum(sb) {
+		return sb, &Errors.DiskError{Kind: Errors.ErrCorrupt, Code: "invalid_superblock", Message: "El checksum del superbloque no coincide, la partición está dañada. " +
+			"Si se formateó antes de que se guardaran los checksums, fsck -repair lo recalcula"}
+	}
+	return sb, nil
+}
+
+// Funcion para leer el superbloque sin verificar su checksum, fsck la usa para poder repararlo
+func readSuperblock(file Utilities.BlockDevice, partition Structs.Partition) (Structs.Superblock, error) {
+	var sb Structs.Superblock
+	if err := Utilities.ReadObject(file, &sb, int64(partition.Start)); err != nil {
+		return sb, ioFailure(err, "No se pudo leer el superbloque de la partición")
+	}
+	if sb.S_magic != 0xEF53 {
+		return sb, ErrNotFormatted
+	}
+	return sb, nil
+}
+
+// Funcion para escribir el superbloque al inicio de la partición con su checksum actualizado
+func WriteSuperblock(file Utilities.BlockDevice, partition Structs.Partition, sb Structs.Superblock) error {
+	sb.S_checksum = Utilities.Checksum(sb)
+	if err := Utilities.WriteObject(file, sb, int64(partition.Start)); err != nil {
+		return ioFailure(err, "No se pudo escribir el superbloque de la partición")
+	}
+	return nil
+}
+
+// Funcion para leer el inodo con el indice dado desde la tabla de inodos
+func ReadInode(file Utilities.BlockDevice, sb Structs.Superblock, index int32) (Structs.Inode, error) {
+	var inode Structs.Inode
+	if index < 0 || index >= sb.S_inodes_count {
+		return inode, corrupt("Inodo %d fuera de rango", index)
+	}
+	position := int64(sb.S_inode_start) + int64(index)*int64(sb.S_inode_size)
+	if err := Utilities.ReadObject(file, &inode, position); err != nil {
+		return inode, ioFailure(err, fmt.Sprintf("No se pudo leer el inodo %d", index))
+	}
+	return inode, nil
+}
+
+// Funcion para leer un bloque (carpeta, archivo o apuntadores) con el indice dado
+func ReadBlock(file Utilities.BlockDevice, sb Structs.Superblock, index int32, block interface{}) error {
+	if index < 0 || index >= sb.S_blocks_count {
+		return corrupt("Bloque %d fuera de rango", index)
+	}
+	position := int64(sb.S_block_start) + int64(index)*int64(sb.S_block_size)
+	if err := Utilities.ReadObject(file, block, position); err != nil {
+		return ioFailure(err, fmt.Sprintf("No se pudo leer el bloque %d", index))
+	}
+	return nil
+}
+
+// Funcion para obtener, en orden, los bloques de datos de un inodo
+// I_block[0..11] son directos, I_block[12] es indirecto simple, [13] doble y [14] triple
+func InodeBlocks(file Utilities.BlockDevice, sb Structs.Superblock, inode Structs.Inode) ([]int32, error) {
+	var blocks []int32
+	for i := 0; i < 12; i++ {
+		if inode.I_block[i] != -1 {
+			blocks = append(blocks, inode.I_block[i])
+		}
+	}
+
+	for level := 1; level <= 3; level++ {
+		pointer := inode.I_block[11+level]
+		if pointer == -1 {
+			continue
+		}
+		indirect, err := indirectBlocks(file, sb, pointer, level)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, indirect...)
+	}
+	return blocks, nil
+}
+
+// Funcion recursiva para recorrer los bloques de apuntadores
+func indirectBlocks(file Utilities.BlockDevice, sb Structs.Superblock, index int32, level int) ([]int32, error) {
+	var pointers Structs.Pointerblock
+	if err := ReadBlock(file, sb, index, &pointers); err != nil {
+		return nil, err
+	}
+
+	var blocks []int32
+	for _, pointer := range pointers.B_pointers {
+		if pointer == -1 {
+			continue
+		}
+		if level == 1 {
+			blocks = append(blocks, pointer)
+			continue
+		}
+		nested, err := indirectBlocks(file, sb, pointer, level-1)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, nested...)
+	}
+	return blocks, nil
+}
+
+// Funcion para obtener las entradas de una carpeta, sin incluir "." y ".."
+func ReadDirectory(file Utilities.BlockDevice, sb Structs.Superblock, inode Structs.Inode) ([]Structs.Content, error) {
+	if inode.I_type[0] != '0' {
+		return nil, &Errors.DiskError{Kind: Errors.ErrInvalid, Code: "not_a_directory", Message: "El inodo no corresponde a una carpeta"}
+	}
+
+	blocks, err := InodeBlocks(file, sb, inode)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Structs.Content
+	for _, index := range blocks {
+		var folder Structs.Folderblock
+		if err := ReadBlock(file, sb, index, &folder); err != nil {
+			return nil, err
+		}
+		for _, content := range folder.B_content {
+			name := ContentName(content)
+			if content.B_inodo == -1 || name == "" || name == "." || name == ".." {
+				continue
+			}
+			entries = append(entries, content)
+		}
+	}
+	return entries, nil
+}
+
+// Funcion para obtener el nombre de una entrada de carpeta sin caracteres nulos
+func ContentName(content Structs.Content) string {
+	return strings.TrimRight(string(content.B_name[:]), "\x00")
+}
+
+// Funcion para leer el contenido completo de un archivo
+func ReadFileContent(file Utilities.BlockDevice, sb Structs.Superblock, inode Structs.Inode) (string, error) {
+	content, err := ReadFileBytes(file, sb, inode)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(content), "\x00"), nil
+}
+
+// Funcion para leer los bytes de un archivo tal como están guardados, recortados a I_size
+func ReadFileBytes(file Utilities.BlockDevice, sb Structs.Superblock, inode Structs.Inode) ([]byte, error) {
+	if inode.I_type[0] != '1' {
+		return nil, &Errors.DiskError{Kind: Errors.ErrInvalid, Code: "not_a_file", Message: "El inodo no corresponde a un archivo"}
+	}
+
+	blocks, err := InodeBlocks(file, sb, inode)
+	if err != nil {
+		return nil, err
+	}
+
+	var content []byte
+	for _, index := range blocks {
+		var fileBlock Structs.Fileblock
+		if err := ReadBlock(file, sb, index, &fileBlock); err != nil {
+			return nil, err
+		}
+		content = append(content, fileBlock.B_content[:]...)
+	}
+
+	if int(inode.I_size) < len(content) {
+		content = content[:inode.I_size]
+	}
+	return content, nil
+}
+
+// Funcion para buscar el inodo de una ruta absoluta, partiendo del inodo raíz (0)
+func SearchInode(file Utilities.BlockDevice, sb Structs.Superblock, path string) (int32, error) {
+	current := int32(0)
+	for _, name := range strings.Split(path, "/") {
+		if name == "" {
+			continue
+		}
+
+		inode, err := ReadInode(file, sb, current)
+		if err != nil {
+			return -1, err
+		}
+		if inode.I_type[0] != '0' {
+			return -1, &Errors.DiskError{Kind: Errors.ErrNotFound, Code: "path_not_found", Message: fmt.Sprintf("La ruta %s no existe", path)}
+		}
+
+		entries, err := ReadDirectory(file, sb, inode)
+		if err != nil {
+			return -1, err
+		}
+
+		found := false
+		for _, entry := range entries {
+			if ContentName(entry) == name {
+				current = entry.B_inodo
+				found = true
+				break
+			}
+		}
+		if !found {
+			return -1, &Errors.DiskError{Kind: Errors.ErrNotFound, Code: "path_not_found", Message: fmt.Sprintf("La ruta %s no existe", path)}
+		}
+	}
+	return current, nil
+}
+
+// Funcion para obtener los nombres de usuarios y grupos a partir de /users.txt
+// Devuelve dos mapas: UID -> usuario y GID -> grupo
+func ReadUsersAndGroups(file Utilities.BlockDevice, sb Structs.Superblock) (map[int32]string, map[int32]string, error) {
+	users := make(map[int32]string)
+	groups := make(map[int32]string)
+
+	index, err := SearchInode(file, sb, "/users.txt")
+	if err != nil {
+		return users, groups, err
+	}
+	inode, err := ReadInode(file, sb, index)
+	if err != nil {
+		return users, groups, err
+	}
+	content, err := ReadFileContent(file, sb, inode)
+	if err != nil {
+		return users, groups, err
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Split(line, ",")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		if len(fields) < 3 {
+			continue
+		}
+		id, err := strconv.Atoi(fields[0])
+		// Los registros eliminados tienen ID 0
+		if err != nil || id == 0 {
+			continue
+		}
+		if fields[1] == "G" {
+			groups[int32(id)] = fields[2]
+		} else if fields[1] == "U" && len(fields) >= 4 {
+			users[int32(id)] = fields[3]
+		}
+	}
+	return users, groups, nil
+}
+
+// Funcion para convertir los permisos UGO (por ejemplo "664") al formato de ls -l
+func PermissionString(inode Structs.Inode) string {
+	result := "-"
+	if inode.I_type[0] == '0' {
+		result = "d"
+	}
+	for _, digit := range inode.I_perm {
+		value := int(digit - '0')
+		if value < 0 || value > 7 {
+			value = 0
+		}
+		for i, flag := range "rwx" {
+			if value&(4>>i) != 0 {
+				result += string(flag)
+			} else {
+				result += "-"
+			}
+		}
+	}
+	return result
+}
+
+// Funcion para leer las entradas del journaling de una partición EXT3
+// El journaling se encuentra justo después del superbloque y tiene una entrada por cada inodo
+func ReadJournal(file Utilities.BlockDevice, partition Structs.Partition, sb Structs.Superblock) ([]Structs.Journal, error) {
+	if sb.S_filesystem_type != 3 {
+		return nil, fmt.Errorf("La partición no tiene un sistema de archivos EXT3")
+	}
+
+	journalStart := int64(partition.Start) + int64(binary.Size(sb))
+	journalSize := int64(binary.Size(Structs.Journal{}))
+
+	var entries []Structs.Journal
+	for i := int32(0); i < sb.S_inodes_count; i++ {
+		var journal Structs.Journal
+		if err := Utilities.ReadObject(file, &journal, journalStart+int64(i)*journalSize); err != nil {
+			return nil, ioFailure(err, fmt.Sprintf("No se pudo leer la entrada %d del journaling", i))
+		}
+		// Las entradas se escriben en orden, la primera vacía marca el final
+		if journal.J_content.I_operation[0] == 0 {
+			break
+		}
+		entries = append(entries, journal)
+	}
+	return entries, nil
+}
@@ -0,0 +1,250 @@
+package FileSystem
+
+import (
+	"fmt"
+	"proyecto1/Errors"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+)
+
+// Los bitmaps guardan un byte por inodo o bloque, 0 libre y cualquier otro valor ocupado
+const (
+	bitmapFree byte = 0
+	bitmapUsed byte = 1
+)
+
+// Estado del recorrido de fsck sobre el sistema de archivos
+type fsckWalk struct {
+	file       Utilities.BlockDevice
+	sb         Structs.Superblock
+	usedInodes map[int32]bool
+	usedBlocks map[int32]bool
+	issues     []Errors.FsckIssue
+}
+
+// Funcion para verificar los bitmaps, los contadores del superbloque y los inodos huérfanos de una partición
+// Se recorre el árbol desde la raíz para saber qué inodos y bloques están realmente en uso
+// Con repair los bitmaps se reconstruyen a partir del recorrido, liberando los inodos huérfanos y sus bloques,
+// y los contadores y el checksum del superbloque se recalculan
+func CheckFileSystem(file Utilities.BlockDevice, partition Structs.Partition, repair bool) ([]Errors.FsckIssue, error) {
+	sb, err := readSuperblock(file, partition)
+	if err != nil {
+		return nil, err
+	}
+	// Sin checksum válido solo se revisa si la estructura cabe en la partición, si no el recorrido no tiene sentido
+	checksumValid := sb.S_checksum == Utilities.Checksum(sb)
+	if !checksumValid && !superblockFits(sb, partition) {
+		return nil, corrupt("El checksum del superbloque no coincide y sus valores no corresponden a la partición, no se puede revisar")
+	}
+
+	inodeBitmap := make([]byte, sb.S_inodes_count)
+	if err := Utilities.ReadObject(file, inodeBitmap, int64(sb.S_bm_inode_start)); err != nil {
+		return nil, ioFailure(err, "No se pudo leer el bitmap de inodos")
+	}
+	blockBitmap := make([]byte, sb.S_blocks_count)
+	if err := Utilities.ReadObject(file, blockBitmap, int64(sb.S_bm_block_start)); err != nil {
+		return nil, ioFailure(err, "No se pudo leer el bitmap de bloques")
+	}
+
+	walk := &fsckWalk{file: file, sb: sb, usedInodes: make(map[int32]bool), usedBlocks: make(map[int32]bool)}
+	if !checksumValid {
+		walk.report(Errors.CheckSuperblock, repair, "El checksum del superbloque no coincide")
+	}
+	if err := walk.visit(0, "/"); err != nil {
+		return nil, err
+	}
+
+	// Bitmaps contra el uso real de inodos y bloques
+	for index := int32(0); index < sb.S_inodes_count; index++ {
+		if walk.usedInodes[index] && inodeBitmap[index] == bitmapFree {
+			walk.report(Errors.CheckBitmaps, repair, "El inodo %d está en uso pero el bitmap lo marca libre", index)
+		}
+	}
+	for index := int32(0); index < sb.S_blocks_count; index++ {
+		if walk.usedBlocks[index] && blockBitmap[index] == bitmapFree {
+			walk.report(Errors.CheckBitmaps, repair, "El bloque %d está en uso pero el bitmap lo marca libre", index)
+		}
+	}
+
+	// Inodos marcados en el bitmap a los que ninguna carpeta hace referencia, con sus bloques
+	orphanBlocks := make(map[int32]bool)
+	for index := int32(0); index < sb.S_inodes_count; index++ {
+		if inodeBitmap[index] == bitmapFree || walk.usedInodes[index] {
+			continue
+		}
+		walk.report(Errors.CheckOrphans, repair, "El inodo %d está marcado en uso pero ninguna carpeta lo referencia", index)
+		if inode, err := ReadInode(file, sb, index); err == nil {
+			walk.inodeBlocks(inode, "", orphanBlocks)
+		}
+	}
+	for index := int32(0); index < sb.S_blocks_count; index++ {
+		if blockBitmap[index] != bitmapFree && !walk.usedBlocks[index] && !orphanBlocks[index] {
+			walk.report(Errors.CheckBitmaps, repair, "El bloque %d está marcado en uso pero ningún inodo lo referencia", index)
+		}
+	}
+
+	// Contadores del superbloque contra los bitmaps actuales
+	freeInodes, freeBlocks := countFree(inodeBitmap), countFree(blockBitmap)
+	if sb.S_free_inodes_count != freeInodes {
+		walk.report(Errors.CheckCounters, repair, "El superbloque indica %d inodos libres pero el bitmap tiene %d", sb.S_free_inodes_count, freeInodes)
+	}
+	if sb.S_free_blocks_count != freeBlocks {
+		walk.report(Errors.CheckCounters, repair, "El superbloque indica %d bloques libres pero el bitmap tiene %d", sb.S_free_blocks_count, freeBlocks)
+	}
+
+	if !repair || len(walk.issues) == 0 {
+		return walk.issues, nil
+	}
+
+	// Reparación, los bitmaps se reconstruyen con lo que se alcanzó desde la raíz
+	for index := range inodeBitmap {
+		inodeBitmap[index] = bitmapFree
+		if walk.usedInodes[int32(index)] {
+			inodeBitmap[index] = bitmapUsed
+		}
+	}
+	for index := range blockBitmap {
+		blockBitmap[index] = bitmapFree
+		if walk.usedBlocks[int32(index)] {
+			blockBitmap[index] = bitmapUsed
+		}
+	}
+	if err := Utilities.WriteObject(file, inodeBitmap, int64(sb.S_bm_inode_start)); err != nil {
+		return walk.issues, ioFailure(err, "No se pudo escribir el bitmap de inodos")
+	}
+	if err := Utilities.WriteObject(file, blockBitmap, int64(sb.S_bm_block_start)); err != nil {
+		return walk.issues, ioFailure(err, "No se pudo escribir el bitmap de bloques")
+	}
+
+	sb.S_free_inodes_count = countFree(inodeBitmap)
+	sb.S_free_blocks_count = countFree(blockBitmap)
+	if err := WriteSuperblock(file, partition, sb); err != nil {
+		return walk.issues, err
+	}
+	return walk.issues, nil
+}
+
+// Funcion para verificar que los bitmaps y las tablas de inodos y bloques del superbloque estén dentro de la partición
+func superblockFits(sb Structs.Superblock, partition Structs.Partition) bool {
+	end := int64(partition.Start) + int64(partition.Size)
+	return sb.S_inodes_count > 0 && sb.S_blocks_count > 0 && sb.S_inode_size > 0 && sb.S_block_size > 0 &&
+		sb.S_bm_inode_start >= partition.Start && int64(sb.S_bm_inode_start)+int64(sb.S_inodes_count) <= end &&
+		sb.S_bm_block_start >= partition.Start && int64(sb.S_bm_block_start)+int64(sb.S_blocks_count) <= end &&
+		sb.S_inode_start >= partition.Start && int64(sb.S_inode_start)+int64(sb.S_inodes_count)*int64(sb.S_inode_size) <= end &&
+		sb.S_block_start >= partition.Start && int64(sb.S_block_start)+int64(sb.S_blocks_count)*int64(sb.S_block_size) <= end
+}
+
+// Funcion para agregar un problema encontrado durante la verificación
+func (w *fsckWalk) report(check string, repaired bool, format string, args ...interface{}) {
+	w.issues = append(w.issues, Errors.FsckIssue{Check: check, Message: fmt.Sprintf(format, args...), Repaired: repaired})
+}
+
+// Funcion recursiva para marcar como usados un inodo, sus bloques y, si es carpeta, su contenido
+func (w *fsckWalk) visit(index int32, path string) error {
+	if w.usedInodes[index] {
+		return nil
+	}
+	inode, err := ReadInode(w.file, w.sb, index)
+	if err != nil {
+		return err
+	}
+	w.usedInodes[index] = true
+
+	blocks := w.inodeBlocks(inode, path, w.usedBlocks)
+	if inode.I_type[0] != '0' {
+		return nil
+	}
+
+	for _, block := range blocks {
+		var folder Structs.Folderblock
+		if err := ReadBlock(w.file, w.sb, block, &folder); err != nil {
+			return err
+		}
+		for _, content := range folder.B_content {
+			name := ContentName(content)
+			if content.B_inodo == -1 || name == "" || name == "." || name == ".." {
+				continue
+			}
+			childPath := path + name
+			if path != "/" {
+				childPath = path + "/" + name
+			}
+			if content.B_inodo < 0 || content.B_inodo >= w.sb.S_inodes_count {
+				w.report(Errors.CheckBitmaps, false, "La entrada %s apunta al inodo %d, fuera de la tabla de inodos", childPath, content.B_inodo)
+				continue
+			}
+			if err := w.visit(content.B_inodo, childPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Funcion para obtener los bloques de datos de un inodo, marcando en marks tanto esos bloques como sus bloques de apuntadores
+// Los apuntadores fuera de la tabla de bloques se ignoran y, si se indica la ruta del inodo, se reportan
+func (w *fsckWalk) inodeBlocks(inode Structs.Inode, path string, marks map[int32]bool) []int32 {
+	var blocks []int32
+	for i := 0; i < 15; i++ {
+		pointer := inode.I_block[i]
+		if pointer == -1 {
+			continue
+		}
+		if i < 12 {
+			if w.markBlock(pointer, path, marks) {
+				blocks = append(blocks, pointer)
+			}
+			continue
+		}
+		blocks = append(blocks, w.indirectBlocks(pointer, i-11, path, marks)...)
+	}
+	return blocks
+}
+
+func (w *fsckWalk) indirectBlocks(index int32, level int, path string, marks map[int32]bool) []int32 {
+	if !w.markBlock(index, path, marks) {
+		return nil
+	}
+	var pointers Structs.Pointerblock
+	if err := ReadBlock(w.file, w.sb, index, &pointers); err != nil {
+		return nil
+	}
+
+	var blocks []int32
+	for _, pointer := range pointers.B_pointers {
+		if pointer == -1 {
+			continue
+		}
+		if level == 1 {
+			if w.markBlock(pointer, path, marks) {
+				blocks = append(blocks, pointer)
+			}
+			continue
+		}
+		blocks = append(blocks, w.indirectBlocks(pointer, level-1, path, marks)...)
+	}
+	return blocks
+}
+
+// Funcion para validar que un apuntador esté dentro de la tabla de bloques y marcarlo
+func (w *fsckWalk) markBlock(index int32, path string, marks map[int32]bool) bool {
+	if index < 0 || index >= w.sb.S_blocks_count {
+		if path != "" {
+			w.report(Errors.CheckBitmaps, false, "%s apunta al bloque %d, fuera de la tabla de bloques", path, index)
+		}
+		return false
+	}
+	marks[index] = true
+	return true
+}
+
+// Funcion para contar las posiciones libres de un bitmap
+func countFree(bitmap []byte) int32 {
+	var free int32
+	for _, value := range bitmap {
+		if value == bitmapFree {
+			free++
+		}
+	}
+	return free
+}
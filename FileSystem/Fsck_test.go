@@ -0,0 +1,97 @@
+package FileSystem
+
+import (
+	"errors"
+	"proyecto1/Errors"
+	"proyecto1/Structs"
+	"strings"
+	"testing"
+)
+
+func TestCheckFileSystemRepairsOrphans(t *testing.T) {
+	file, partition := newTestPartition(t, 64*1024, false)
+	writer, err := NewWriter(file, partition)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sb := writer.Superblock()
+	freeInodes, freeBlocks := sb.S_free_inodes_count, sb.S_free_blocks_count
+	orphan, err := writer.WriteFile(0, "huerfano.txt", []byte(strings.Repeat("a", 200)), RootUID, 1, "664")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Se borra la entrada de la raíz sin liberar el inodo, como si se hubiera interrumpido un rm
+	sb = writer.Superblock()
+	root, err := ReadInode(file, sb, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var folder Structs.Folderblock
+	if err := ReadBlock(file, sb, root.I_block[0], &folder); err != nil {
+		t.Fatal(err)
+	}
+	for i := range folder.B_content {
+		if folder.B_content[i].B_inodo == orphan {
+			folder.B_content[i] = Structs.Content{B_inodo: -1}
+		}
+	}
+	mustWrite(t, file, folder, int64(sb.S_block_start)+int64(root.I_block[0])*int64(sb.S_block_size))
+
+	issues, err := CheckFileSystem(file, partition, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	orphans := 0
+	for _, issue := range issues {
+		if issue.Check == Errors.CheckOrphans {
+			orphans++
+		}
+		if !issue.Repaired {
+			t.Errorf("fsck no reparó: %s: %s", issue.Check, issue.Message)
+		}
+	}
+	if orphans != 1 {
+		t.Fatalf("Se esperaba un inodo huérfano y se obtuvo %+v", issues)
+	}
+
+	// El inodo y sus bloques vuelven a estar libres
+	assertClean(t, file, partition)
+	sb, err = ReadSuperblock(file, partition)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sb.S_free_inodes_count != freeInodes || sb.S_free_blocks_count != freeBlocks {
+		t.Errorf("Después de reparar quedaron %d inodos y %d bloques libres, se esperaban %d y %d", sb.S_free_inodes_count, sb.S_free_blocks_count, freeInodes, freeBlocks)
+	}
+}
+
+func TestCheckFileSystemRepairsChecksum(t *testing.T) {
+	file, partition := newTestPartition(t, 64*1024, false)
+	sb, err := ReadSuperblock(file, partition)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Una partición formateada antes de que el superbloque tuviera checksum
+	sb.S_checksum = 0
+	mustWrite(t, file, sb, int64(partition.Start))
+	if _, err := ReadSuperblock(file, partition); !errors.Is(err, Errors.ErrCorrupt) || !strings.Contains(err.Error(), "fsck -repair") {
+		t.Fatalf("Se esperaba un error de checksum que indique fsck -repair y se obtuvo %v", err)
+	}
+
+	issues, err := CheckFileSystem(file, partition, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 || issues[0].Check != Errors.CheckSuperblock || !issues[0].Repaired {
+		t.Fatalf("Se esperaba solo el checksum del superbloque reparado y se obtuvo %+v", issues)
+	}
+	if _, err := ReadSuperblock(file, partition); err != nil {
+		t.Fatal(err)
+	}
+	assertClean(t, file, partition)
+}
@@ -0,0 +1,97 @@
+package FileSystem
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+)
+
+// Resumen de lo que se copió con Import
+// Skipped son las rutas del sistema anfitrión que no se copiaron, cada una con el motivo
+type ImportResult struct {
+	Directories int
+	Files       int
+	Bytes       int64
+	Skipped     []string
+}
+
+// Funcion para copiar recursivamente una carpeta del sistema anfitrión a una ruta de la partición
+// Las carpetas de la ruta destino que no existan se crean, los archivos existentes se reemplazan
+// Si se acaban los inodos o bloques la copia se detiene, la partición conserva las carpetas y archivos que se copiaron completos
+func Import(file Utilities.BlockDevice, partition Structs.Partition, src string, dest string, uid int32, gid int32) (ImportResult, error) {
+	var result ImportResult
+
+	info, err := os.Stat(src)
+	if err != nil {
+		return result, fmt.Errorf("No se pudo leer la carpeta %s: %v", src, err)
+	}
+	if !info.IsDir() {
+		return result, fmt.Errorf("%s no es una carpeta", src)
+	}
+
+	writer, err := NewWriter(file, partition)
+	if err != nil {
+		return result, err
+	}
+
+	root, err := writer.MkdirAll(dest, uid, gid, "775")
+	if err == nil {
+		err = importDirectory(writer, src, root, uid, gid, &result)
+	}
+
+	// El superbloque se actualiza aunque la copia haya fallado, para que los contadores coincidan con los bitmaps
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	return result, err
+}
+
+func importDirectory(writer *Writer, src string, parent int32, uid int32, gid int32, result *ImportResult) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return fmt.Errorf("No se pudo leer la carpeta %s: %v", src, err)
+	}
+
+	for _, entry := range entries {
+		hostPath := filepath.Join(src, entry.Name())
+		info, err := entry.Info()
+		if err != nil {
+			return fmt.Errorf("No se pudo leer %s: %v", hostPath, err)
+		}
+		perm := fmt.Sprintf("%03o", info.Mode().Perm())
+
+		// Un nombre que no cabe en una entrada de carpeta se omite en lugar de detener la copia a medias
+		if len(entry.Name()) > len(Structs.Content{}.B_name) {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s: el nombre tiene más de %d caracteres", hostPath, len(Structs.Content{}.B_name)))
+			continue
+		}
+
+		switch {
+		case info.IsDir():
+			index, err := writer.Mkdir(parent, entry.Name(), uid, gid, perm)
+			if err != nil {
+				return fmt.Errorf("%s: %w", hostPath, err)
+			}
+			result.Directories++
+			if err := importDirectory(writer, hostPath, index, uid, gid, result); err != nil {
+				return err
+			}
+		case info.Mode().IsRegular():
+			content, err := os.ReadFile(hostPath)
+			if err != nil {
+				return fmt.Errorf("No se pudo leer %s: %v", hostPath, err)
+			}
+			if _, err := writer.WriteFile(parent, entry.Name(), content, uid, gid, perm); err != nil {
+				return fmt.Errorf("%s: %w", hostPath, err)
+			}
+			result.Files++
+			result.Bytes += int64(len(content))
+		default:
+			// Los enlaces y archivos especiales no tienen equivalente en el sistema de archivos
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s: no es una carpeta ni un archivo regular", hostPath))
+		}
+	}
+	return nil
+}
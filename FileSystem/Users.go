@@ -0,0 +1,141 @@
+package FileSystem
+
+import (
+	"fmt"
+	"path"
+	"proyecto1/Errors"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+	"strconv"
+	"strings"
+)
+
+// UID del usuario root, creado al formatear la partición
+const RootUID = int32(1)
+
+// Usuario registrado en /users.txt
+type User struct {
+	UID   int32
+	GID   int32
+	Name  string
+	Group string
+}
+
+// Funcion para validar un usuario y contraseña contra /users.txt
+// El GID del usuario es el del grupo al que pertenece
+func Authenticate(file Utilities.BlockDevice, sb Structs.Superblock, name string, password string) (User, error) {
+	index, err := SearchInode(file, sb, "/users.txt")
+	if err != nil {
+		return User{}, err
+	}
+	inode, err := ReadInode(file, sb, index)
+	if err != nil {
+		return User{}, err
+	}
+	content, err := ReadFileContent(file, sb, inode)
+	if err != nil {
+		return User{}, err
+	}
+
+	groups := make(map[string]int32)
+	var records [][]string
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Split(line, ",")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		if len(fields) < 3 {
+			continue
+		}
+		id, err := strconv.Atoi(fields[0])
+		// Los registros eliminados tienen ID 0
+		if err != nil || id == 0 {
+			continue
+		}
+		if fields[1] == "G" {
+			groups[fields[2]] = int32(id)
+		} else if fields[1] == "U" && len(fields) >= 5 {
+			records = append(records, fields)
+		}
+	}
+
+	for _, fields := range records {
+		if fields[3] != name || fields[4] != password {
+			continue
+		}
+		uid, _ := strconv.Atoi(fields[0])
+		return User{UID: int32(uid), GID: groups[fields[2]], Name: fields[3], Group: fields[2]}, nil
+	}
+	return User{}, &Errors.DiskError{Kind: Errors.ErrDenied, Code: "bad_credentials", Message: "Usuario o contraseña incorrectos"}
+}
+
+// Funcion para verificar si el usuario puede leer un inodo según sus permisos UGO, root siempre puede
+func (u User) CanRead(inode Structs.Inode) bool {
+	return u.permission(inode)&4 != 0
+}
+
+// Funcion para verificar si el usuario puede escribir un inodo según sus permisos UGO, root siempre puede
+func (u User) CanWrite(inode Structs.Inode) bool {
+	return u.permission(inode)&2 != 0
+}
+
+// Funcion para obtener el dígito de permisos (propietario, grupo u otros) que aplica al usuario
+func (u User) permission(inode Structs.Inode) int {
+	if u.UID == RootUID {
+		return 7
+	}
+	digit := inode.I_perm[2]
+	if inode.I_uid == u.UID {
+		digit = inode.I_perm[0]
+	} else if inode.I_gid == u.GID {
+		digit = inode.I_perm[1]
+	}
+	if digit < '0' || digit > '7' {
+		return 0
+	}
+	return int(digit - '0')
+}
+
+// Funcion para verificar que el usuario pueda eliminar una ruta y todo su contenido
+// Igual que en la consola, se necesita permiso de escritura sobre cada archivo y carpeta que se elimina
+// Se recorre todo antes de eliminar, así una carpeta con un archivo ajeno no se elimina a medias
+func (u User) CanRemove(file Utilities.BlockDevice, sb Structs.Superblock, index int32, fsPath string) error {
+	visited := make(map[int32]bool)
+	var walk func(index int32, fsPath string) error
+	walk = func(index int32, fsPath string) error {
+		if index < 0 || index >= sb.S_inodes_count {
+			return corrupt("La entrada %s apunta al inodo %d, fuera de la tabla de inodos", fsPath, index)
+		}
+		if visited[index] {
+			return nil
+		}
+		visited[index] = true
+
+		inode, err := ReadInode(file, sb, index)
+		if err != nil {
+			return err
+		}
+		if !u.CanWrite(inode) {
+			return PermissionDenied(fsPath)
+		}
+		if inode.I_type[0] != '0' {
+			return nil
+		}
+		entries, err := ReadDirectory(file, sb, inode)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := walk(entry.B_inodo, path.Join(fsPath, ContentName(entry))); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(index, fsPath)
+}
+
+// Funcion para construir el error de permiso denegado sobre una ruta
+func PermissionDenied(fsPath string) error {
+	return &Errors.DiskError{Kind: Errors.ErrDenied, Code: "permission_denied", Message: fmt.Sprintf("El usuario no tiene permisos sobre %s", fsPath)}
+}
@@ -0,0 +1,799 @@
+package FileSystem
+
+import (
+	"encoding/binary"
+	"fmt"
+	"path"
+	"proyecto1/Errors"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+	"strings"
+	"time"
+)
+
+// Cantidad de apuntadores en un bloque de apuntadores
+const pointersPerBlock = int32(len(Structs.Pointerblock{}.B_pointers))
+
+// Tamaño máximo de un archivo: 12 bloques directos más los que alcanzan los apuntadores simple, doble y triple
+const MaxFileSize = int64(12+pointersPerBlock+pointersPerBlock*pointersPerBlock+pointersPerBlock*pointersPerBlock*pointersPerBlock) * int64(len(Structs.Fileblock{}.B_content))
+
+// Escritor de una partición formateada, mantiene los bitmaps en memoria y los escribe al asignar o liberar
+// El superbloque con los contadores actualizados se escribe en Close
+type Writer struct {
+	file        Utilities.BlockDevice
+	partition   Structs.Partition
+	sb          Structs.Superblock
+	inodeBitmap []byte
+	blockBitmap []byte
+}
+
+// Funcion para abrir un escritor sobre una partición formateada
+func NewWriter(file Utilities.BlockDevice, partition Structs.Partition) (*Writer, error) {
+	sb, err := ReadSuperblock(file, partition)
+	if err != nil {
+		return nil, err
+	}
+	w := &Writer{
+		file:        file,
+		partition:   partition,
+		sb:          sb,
+		inodeBitmap: make([]byte, sb.S_inodes_count),
+		blockBitmap: make([]byte, sb.S_blocks_count),
+	}
+	if err := Utilities.ReadObject(file, w.inodeBitmap, int64(sb.S_bm_inode_start)); err != nil {
+		return nil, ioFailure(err, "No se pudo leer el bitmap de inodos")
+	}
+	if err := Utilities.ReadObject(file, w.blockBitmap, int64(sb.S_bm_block_start)); err != nil {
+		return nil, ioFailure(err, "No se pudo leer el bitmap de bloques")
+	}
+	return w, nil
+}
+
+// Superbloque con los cambios hechos por el escritor
+func (w *Writer) Superblock() Structs.Superblock {
+	return w.sb
+}
+
+// Funcion para escribir el superbloque con los contadores actualizados
+func (w *Writer) Close() error {
+	return WriteSuperblock(w.file, w.partition, w.sb)
+}
+
+// Funcion para crear una carpeta con el nombre dado dentro de otra carpeta
+// Si ya existe una carpeta con ese nombre se devuelve su inodo
+func (w *Writer) Mkdir(parent int32, name string, uid int32, gid int32, perm string) (int32, error) {
+	existing, inode, err := w.lookup(parent, name)
+	if err != nil {
+		return -1, err
+	}
+	if existing != -1 {
+		if inode.I_type[0] != '0' {
+			return -1, fmt.Errorf("Ya existe un archivo con el nombre %s", name)
+		}
+		return existing, nil
+	}
+
+	// Se verifica antes de escribir que alcancen los inodos y bloques, para no dejar la carpeta a medias
+	entryBlocks, err := w.entryBlocks(parent)
+	if err != nil {
+		return -1, err
+	}
+	if err := w.reserve(1, 1+entryBlocks); err != nil {
+		return -1, err
+	}
+
+	index, err := w.allocInode()
+	if err != nil {
+		return -1, err
+	}
+	block, err := w.allocBlock()
+	if err != nil {
+		w.setInodeBitmap(index, bitmapFree)
+		return -1, err
+	}
+
+	var folder Structs.Folderblock
+	copy(folder.B_content[0].B_name[:], ".")
+	folder.B_content[0].B_inodo = index
+	copy(folder.B_content[1].B_name[:], "..")
+	folder.B_content[1].B_inodo = parent
+	folder.B_content[2].B_inodo = -1
+	folder.B_content[3].B_inodo = -1
+
+	dir := newInode(uid, gid, '0', perm)
+	dir.I_block[0] = block
+	err = w.writeBlock(block, folder)
+	if err == nil {
+		err = w.writeInode(index, dir)
+	}
+	if err == nil {
+		err = w.addEntry(parent, name, index)
+	}
+	if err != nil {
+		// Si la carpeta no quedó enlazada se liberan su inodo y su bloque
+		w.setBlockBitmap(block, bitmapFree)
+		w.setInodeBitmap(index, bitmapFree)
+		return -1, err
+	}
+	if err := w.journal("mkdir", parent, name, ""); err != nil {
+		return -1, err
+	}
+	return index, nil
+}
+
+// Funcion para crear un archivo con el contenido dado dentro de una carpeta
+// Si ya existe un archivo con ese nombre se reemplaza su contenido
+// Antes de escribir se verifica que alcancen los inodos y bloques, si no el archivo no se crea ni se modifica
+func (w *Writer) WriteFile(parent int32, name string, content []byte, uid int32, gid int32, perm string) (int32, error) {
+	index, inode, err := w.lookup(parent, name)
+	if err != nil {
+		return -1, err
+	}
+
+	blockSize := int32(binary.Size(Structs.Fileblock{}))
+	dataBlocks := (int32(len(content)) + blockSize - 1) / blockSize
+	if index != -1 {
+		if inode.I_type[0] != '1' {
+			return -1, fmt.Errorf("Ya existe una carpeta con el nombre %s", name)
+		}
+		// El contenido nuevo se escribe en bloques nuevos y los actuales se liberan solo si todo funcionó,
+		// así un error (por ejemplo sin espacio) no deja el archivo vacío o a medias
+		if err := w.reserve(0, blocksFor(dataBlocks)); err != nil {
+			return -1, err
+		}
+		updated := inode
+		for i := range updated.I_block {
+			updated.I_block[i] = -1
+		}
+		copy(updated.I_mtime[:], now())
+		err := w.writeContent(&updated, content)
+		if err == nil {
+			err = w.writeInode(index, updated)
+		}
+		if err != nil {
+			w.freeBlocks(updated)
+			return -1, err
+		}
+		if err := w.freeBlocks(inode); err != nil {
+			return -1, err
+		}
+	} else {
+		entryBlocks, err := w.entryBlocks(parent)
+		if err != nil {
+			return -1, err
+		}
+		if err := w.reserve(1, entryBlocks+blocksFor(dataBlocks)); err != nil {
+			return -1, err
+		}
+		if index, err = w.allocInode(); err != nil {
+			return -1, err
+		}
+		inode = newInode(uid, gid, '1', perm)
+		err = w.writeContent(&inode, content)
+		// El inodo se escribe antes de agregarlo a la carpeta para que no quede una entrada a un inodo vacío
+		if err == nil {
+			err = w.writeInode(index, inode)
+		}
+		if err == nil {
+			err = w.addEntry(parent, name, index)
+		}
+		if err != nil {
+			// Si el archivo no quedó enlazado se liberan su inodo y los bloques que alcanzó a usar
+			w.freeBlocks(inode)
+			w.setInodeBitmap(index, bitmapFree)
+			return -1, err
+		}
+	}
+
+	journalContent := string(content)
+	if len(journalContent) > len(Structs.Information{}.I_content) {
+		journalContent = journalContent[:len(Structs.Information{}.I_content)]
+	}
+	if err := w.journal("mkfile", parent, name, journalContent); err != nil {
+		return -1, err
+	}
+	return index, nil
+}
+
+// Funcion para escribir el contenido de un archivo en bloques nuevos, asignándolos al inodo
+// Si falla, el inodo conserva los bloques que alcanzó a asignar para que quien la llama los libere
+func (w *Writer) writeContent(inode *Structs.Inode, content []byte) error {
+	blockSize := binary.Size(Structs.Fileblock{})
+	for logical := 0; logical*blockSize < len(content); logical++ {
+		block, err := w.allocBlock()
+		if err != nil {
+			return err
+		}
+		if err := w.setBlock(inode, int32(logical), block); err != nil {
+			w.setBlockBitmap(block, bitmapFree)
+			return err
+		}
+		var fileBlock Structs.Fileblock
+		copy(fileBlock.B_content[:], content[logical*blockSize:])
+		if err := w.writeBlock(block, fileBlock); err != nil {
+			return err
+		}
+	}
+	inode.I_size = int32(len(content))
+	return nil
+}
+
+// Funcion para crear todas las carpetas de una ruta absoluta que no existan, como mkdir -p
+func (w *Writer) MkdirAll(path string, uid int32, gid int32, perm string) (int32, error) {
+	current := int32(0)
+	for _, name := range strings.Split(path, "/") {
+		if name == "" {
+			continue
+		}
+		next, err := w.Mkdir(current, name, uid, gid, perm)
+		if err != nil {
+			return -1, err
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// Funcion para eliminar un archivo o una carpeta con todo su contenido, liberando sus inodos y bloques
+func (w *Writer) Remove(parent int32, name string) error {
+	index, inode, err := w.lookup(parent, name)
+	if err != nil {
+		return err
+	}
+	if index == -1 {
+		return notFound(name)
+	}
+	if index == 0 {
+		return invalidName(name)
+	}
+
+	// La entrada se quita antes de liberar para que no quede apuntando a un inodo libre si falla a medias
+	if err := w.removeEntry(parent, index); err != nil {
+		return err
+	}
+	if err := w.freeInode(index, inode); err != nil {
+		return err
+	}
+	return w.journal("remove", parent, name, "")
+}
+
+// Funcion para mover o renombrar una entrada, el destino no debe existir
+// Una carpeta no puede moverse dentro de sí misma
+func (w *Writer) Rename(oldParent int32, oldName string, newParent int32, newName string) error {
+	index, inode, err := w.lookup(oldParent, oldName)
+	if err != nil {
+		return err
+	}
+	if index == -1 {
+		return notFound(oldName)
+	}
+	existing, _, err := w.lookup(newParent, newName)
+	if err != nil {
+		return err
+	}
+	if existing != -1 {
+		return &Errors.DiskError{Kind: Errors.ErrInvalid, Code: "entry_exists", Message: fmt.Sprintf("Ya existe una entrada con el nombre %s", newName)}
+	}
+
+	isDir := inode.I_type[0] == '0'
+	if isDir {
+		for current := newParent; current != 0; {
+			if current == index {
+				return &Errors.DiskError{Kind: Errors.ErrInvalid, Message: fmt.Sprintf("No se puede mover la carpeta %s dentro de sí misma", oldName)}
+			}
+			if current, err = w.parentOf(current); err != nil {
+				return err
+			}
+		}
+	}
+
+	oldPath, err := w.inodePath(oldParent)
+	if err != nil {
+		return err
+	}
+	if err := w.removeEntry(oldParent, index); err != nil {
+		return err
+	}
+	if err := w.addEntry(newParent, newName, index); err != nil {
+		return err
+	}
+
+	// La entrada ".." de una carpeta movida debe apuntar a su nueva carpeta padre
+	if isDir && oldParent != newParent {
+		var folder Structs.Folderblock
+		if err := ReadBlock(w.file, w.sb, inode.I_block[0], &folder); err != nil {
+			return err
+		}
+		folder.B_content[1].B_inodo = newParent
+		if err := w.writeBlock(inode.I_block[0], folder); err != nil {
+			return err
+		}
+	}
+	return w.journal("rename", newParent, newName, path.Join(oldPath, oldName))
+}
+
+// Funcion para buscar una entrada por nombre en una carpeta, devuelve -1 si no existe
+func (w *Writer) lookup(parent int32, name string) (int32, Structs.Inode, error) {
+	if name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+		return -1, Structs.Inode{}, invalidName(name)
+	}
+	if len(name) > len(Structs.Content{}.B_name) {
+		return -1, Structs.Inode{}, invalidName(name)
+	}
+
+	dir, err := ReadInode(w.file, w.sb, parent)
+	if err != nil {
+		return -1, Structs.Inode{}, err
+	}
+	entries, err := ReadDirectory(w.file, w.sb, dir)
+	if err != nil {
+		return -1, Structs.Inode{}, err
+	}
+	for _, entry := range entries {
+		if ContentName(entry) == name {
+			inode, err := ReadInode(w.file, w.sb, entry.B_inodo)
+			return entry.B_inodo, inode, err
+		}
+	}
+	return -1, Structs.Inode{}, nil
+}
+
+// Funcion para quitar de una carpeta la entrada que apunta a un inodo, el bloque de carpeta se conserva
+func (w *Writer) removeEntry(parent int32, child int32) error {
+	dir, err := ReadInode(w.file, w.sb, parent)
+	if err != nil {
+		return err
+	}
+	blocks, err := InodeBlocks(w.file, w.sb, dir)
+	if err != nil {
+		return err
+	}
+
+	for _, block := range blocks {
+		var folder Structs.Folderblock
+		if err := ReadBlock(w.file, w.sb, block, &folder); err != nil {
+			return err
+		}
+		for i, content := range folder.B_content {
+			name := ContentName(content)
+			if content.B_inodo != child || name == "." || name == ".." {
+				continue
+			}
+			folder.B_content[i] = Structs.Content{B_inodo: -1}
+			if err := w.writeBlock(block, folder); err != nil {
+				return err
+			}
+			copy(dir.I_mtime[:], now())
+			return w.writeInode(parent, dir)
+		}
+	}
+	return &Errors.DiskError{Kind: Errors.ErrNotFound, Message: fmt.Sprintf("La carpeta %d no tiene una entrada al inodo %d", parent, child)}
+}
+
+// Funcion recursiva para liberar un inodo, sus bloques y, si es carpeta, todo su contenido
+func (w *Writer) freeInode(index int32, inode Structs.Inode) error {
+	if inode.I_type[0] == '0' {
+		entries, err := ReadDirectory(w.file, w.sb, inode)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			// Una entrada dañada no debe tumbar el servidor, se reporta y fsck -repair puede recuperar lo que quede
+			if entry.B_inodo < 0 || entry.B_inodo >= int32(len(w.inodeBitmap)) {
+				return corrupt("La carpeta %d tiene una entrada al inodo %d, fuera de la tabla de inodos", index, entry.B_inodo)
+			}
+			if entry.B_inodo == index || w.inodeBitmap[entry.B_inodo] == bitmapFree {
+				continue
+			}
+			child, err := ReadInode(w.file, w.sb, entry.B_inodo)
+			if err != nil {
+				return err
+			}
+			if err := w.freeInode(entry.B_inodo, child); err != nil {
+				return err
+			}
+		}
+	}
+	if err := w.freeBlocks(inode); err != nil {
+		return err
+	}
+	return w.setInodeBitmap(index, bitmapFree)
+}
+
+// Funcion para obtener la carpeta padre de una carpeta a partir de su entrada ".."
+func (w *Writer) parentOf(index int32) (int32, error) {
+	dir, err := ReadInode(w.file, w.sb, index)
+	if err != nil {
+		return -1, err
+	}
+	var folder Structs.Folderblock
+	if err := ReadBlock(w.file, w.sb, dir.I_block[0], &folder); err != nil {
+		return -1, err
+	}
+	return folder.B_content[1].B_inodo, nil
+}
+
+// Funcion para agregar una entrada a una carpeta, usa el primer espacio libre o un nuevo bloque de carpeta
+func (w *Writer) addEntry(parent int32, name string, child int32) error {
+	dir, err := ReadInode(w.file, w.sb, parent)
+	if err != nil {
+		return err
+	}
+	blocks, err := InodeBlocks(w.file, w.sb, dir)
+	if err != nil {
+		return err
+	}
+
+	copy(dir.I_mtime[:], now())
+	for _, block := range blocks {
+		var folder Structs.Folderblock
+		if err := ReadBlock(w.file, w.sb, block, &folder); err != nil {
+			return err
+		}
+		for i := range folder.B_content {
+			if folder.B_content[i].B_inodo != -1 {
+				continue
+			}
+			folder.B_content[i] = Structs.Content{B_inodo: child}
+			copy(folder.B_content[i].B_name[:], name)
+			if err := w.writeBlock(block, folder); err != nil {
+				return err
+			}
+			return w.writeInode(parent, dir)
+		}
+	}
+
+	block, err := w.allocBlock()
+	if err != nil {
+		return err
+	}
+	var folder Structs.Folderblock
+	for i := range folder.B_content {
+		folder.B_content[i].B_inodo = -1
+	}
+	folder.B_content[0].B_inodo = child
+	copy(folder.B_content[0].B_name[:], name)
+	if err := w.writeBlock(block, folder); err != nil {
+		w.setBlockBitmap(block, bitmapFree)
+		return err
+	}
+	if err := w.setBlock(&dir, int32(len(blocks)), block); err != nil {
+		w.setBlockBitmap(block, bitmapFree)
+		return err
+	}
+	return w.writeInode(parent, dir)
+}
+
+// Funcion para saber cuántos bloques necesita addEntry para agregar una entrada a una carpeta
+// Si la carpeta tiene un espacio libre no necesita ninguno, si no un bloque de carpeta y los de apuntadores que falten
+func (w *Writer) entryBlocks(parent int32) (int32, error) {
+	dir, err := ReadInode(w.file, w.sb, parent)
+	if err != nil {
+		return 0, err
+	}
+	blocks, err := InodeBlocks(w.file, w.sb, dir)
+	if err != nil {
+		return 0, err
+	}
+	for _, block := range blocks {
+		var folder Structs.Folderblock
+		if err := ReadBlock(w.file, w.sb, block, &folder); err != nil {
+			return 0, err
+		}
+		for _, content := range folder.B_content {
+			if content.B_inodo == -1 {
+				return 0, nil
+			}
+		}
+	}
+	count := int32(len(blocks))
+	return blocksFor(count+1) - blocksFor(count), nil
+}
+
+// Funcion para verificar que queden libres al menos los inodos y bloques indicados
+func (w *Writer) reserve(inodes int32, blocks int32) error {
+	if free := countFree(w.inodeBitmap); free < inodes {
+		return noSpace(fmt.Sprintf("No hay inodos libres suficientes en la partición, se necesitan %d y quedan %d", inodes, free))
+	}
+	if free := countFree(w.blockBitmap); free < blocks {
+		return noSpace(fmt.Sprintf("No hay bloques libres suficientes en la partición, se necesitan %d y quedan %d", blocks, free))
+	}
+	return nil
+}
+
+// Funcion para calcular cuántos bloques ocupa un inodo con count bloques de datos, incluyendo los de apuntadores
+func blocksFor(count int32) int32 {
+	total := count
+	remaining := count - 12
+	span := pointersPerBlock
+	for level := 1; level <= 3 && remaining > 0; level++ {
+		used := remaining
+		if used > span {
+			used = span
+		}
+		// Bloques de apuntadores de cada nivel, desde el que apunta a los datos hasta el del inodo
+		for unit := int32(1); unit <= span/pointersPerBlock; unit *= pointersPerBlock {
+			total += (used + unit*pointersPerBlock - 1) / (unit * pointersPerBlock)
+		}
+		remaining -= used
+		span *= pointersPerBlock
+	}
+	return total
+}
+
+// Funcion para asignar el bloque de datos número logical de un inodo, creando los bloques de apuntadores necesarios
+func (w *Writer) setBlock(inode *Structs.Inode, logical int32, block int32) error {
+	if logical < 12 {
+		inode.I_block[logical] = block
+		return nil
+	}
+	logical -= 12
+	span := pointersPerBlock
+	for level := 1; level <= 3; level++ {
+		if logical < span {
+			return w.setPointer(&inode.I_block[11+level], level, logical, block)
+		}
+		logical -= span
+		span *= pointersPerBlock
+	}
+	return noSpace("El archivo excede el tamaño máximo que puede direccionar un inodo")
+}
+
+// Funcion recursiva para escribir un apuntador dentro de un bloque de apuntadores del nivel dado
+func (w *Writer) setPointer(pointer *int32, level int, offset int32, block int32) error {
+	var pointers Structs.Pointerblock
+	if *pointer == -1 {
+		index, err := w.allocBlock()
+		if err != nil {
+			return err
+		}
+		*pointer = index
+		for i := range pointers.B_pointers {
+			pointers.B_pointers[i] = -1
+		}
+	} else if err := ReadBlock(w.file, w.sb, *pointer, &pointers); err != nil {
+		return err
+	}
+
+	if level == 1 {
+		pointers.B_pointers[offset] = block
+	} else {
+		span := int32(1)
+		for i := 1; i < level; i++ {
+			span *= pointersPerBlock
+		}
+		if err := w.setPointer(&pointers.B_pointers[offset/span], level-1, offset%span, block); err != nil {
+			return err
+		}
+	}
+	return w.writeBlock(*pointer, pointers)
+}
+
+// Funcion para liberar los bloques de datos y de apuntadores de un inodo
+func (w *Writer) freeBlocks(inode Structs.Inode) error {
+	for i, pointer := range inode.I_block {
+		if pointer == -1 {
+			continue
+		}
+		if i >= 12 {
+			if err := w.freePointers(pointer, i-11); err != nil {
+				return err
+			}
+		}
+		if err := w.setBlockBitmap(pointer, bitmapFree); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *Writer) freePointers(index int32, level int) error {
+	var pointers Structs.Pointerblock
+	if err := ReadBlock(w.file, w.sb, index, &pointers); err != nil {
+		return err
+	}
+	for _, pointer := range pointers.B_pointers {
+		if pointer == -1 {
+			continue
+		}
+		if level > 1 {
+			if err := w.freePointers(pointer, level-1); err != nil {
+				return err
+			}
+		}
+		if err := w.setBlockBitmap(pointer, bitmapFree); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Funcion para reservar el primer inodo libre
+func (w *Writer) allocInode() (int32, error) {
+	for index, value := range w.inodeBitmap {
+		if value != bitmapFree {
+			continue
+		}
+		if err := w.setInodeBitmap(int32(index), bitmapUsed); err != nil {
+			return -1, err
+		}
+		return int32(index), nil
+	}
+	return -1, noSpace("No hay inodos libres en la partición")
+}
+
+// Funcion para reservar el primer bloque libre
+func (w *Writer) allocBlock() (int32, error) {
+	for index, value := range w.blockBitmap {
+		if value != bitmapFree {
+			continue
+		}
+		if err := w.setBlockBitmap(int32(index), bitmapUsed); err != nil {
+			return -1, err
+		}
+		return int32(index), nil
+	}
+	return -1, noSpace("No hay bloques libres en la partición")
+}
+
+// Funcion para marcar un inodo como usado o libre, actualizando el contador del superbloque
+func (w *Writer) setInodeBitmap(index int32, value byte) error {
+	if index < 0 || index >= w.sb.S_inodes_count || w.inodeBitmap[index] == value {
+		return nil
+	}
+	if err := Utilities.WriteObject(w.file, []byte{value}, int64(w.sb.S_bm_inode_start)+int64(index)); err != nil {
+		return ioFailure(err, "No se pudo escribir el bitmap de inodos")
+	}
+	w.inodeBitmap[index] = value
+	if value == bitmapFree {
+		w.sb.S_free_inodes_count++
+	} else {
+		w.sb.S_free_inodes_count--
+	}
+	w.sb.S_fist_ino = firstFree(w.inodeBitmap)
+	return nil
+}
+
+// Funcion para marcar un bloque como usado o libre, actualizando el contador del superbloque
+func (w *Writer) setBlockBitmap(index int32, value byte) error {
+	if index < 0 || index >= w.sb.S_blocks_count || w.blockBitmap[index] == value {
+		return nil
+	}
+	if err := Utilities.WriteObject(w.file, []byte{value}, int64(w.sb.S_bm_block_start)+int64(index)); err != nil {
+		return ioFailure(err, "No se pudo escribir el bitmap de bloques")
+	}
+	w.blockBitmap[index] = value
+	if value == bitmapFree {
+		w.sb.S_free_blocks_count++
+	} else {
+		w.sb.S_free_blocks_count--
+	}
+	w.sb.S_first_blo = firstFree(w.blockBitmap)
+	return nil
+}
+
+func (w *Writer) writeInode(index int32, inode Structs.Inode) error {
+	position := int64(w.sb.S_inode_start) + int64(index)*int64(w.sb.S_inode_size)
+	if err := Utilities.WriteObject(w.file, inode, position); err != nil {
+		return ioFailure(err, fmt.Sprintf("No se pudo escribir el inodo %d", index))
+	}
+	return nil
+}
+
+func (w *Writer) writeBlock(index int32, block interface{}) error {
+	position := int64(w.sb.S_block_start) + int64(index)*int64(w.sb.S_block_size)
+	if err := Utilities.WriteObject(w.file, block, position); err != nil {
+		return ioFailure(err, fmt.Sprintf("No se pudo escribir el bloque %d", index))
+	}
+	return nil
+}
+
+// Funcion para registrar una operación en el journaling, solo en particiones EXT3
+func (w *Writer) journal(operation string, parent int32, name string, content string) error {
+	if w.sb.S_filesystem_type != 3 {
+		return nil
+	}
+	entries, err := ReadJournal(w.file, w.partition, w.sb)
+	if err != nil {
+		return err
+	}
+	// El journaling tiene una entrada por inodo, cuando se llena ya no se registran operaciones
+	if int32(len(entries)) >= w.sb.S_inodes_count {
+		return nil
+	}
+
+	path, err := w.inodePath(parent)
+	if err != nil {
+		return err
+	}
+	if path != "/" {
+		path += "/"
+	}
+
+	journal := Structs.Journal{J_count: int32(len(entries)) + 1}
+	copy(journal.J_content.I_operation[:], operation)
+	copy(journal.J_content.I_path[:], path+name)
+	copy(journal.J_content.I_content[:], content)
+	copy(journal.J_content.I_date[:], now())
+	position := int64(w.partition.Start) + int64(binary.Size(w.sb)) + int64(len(entries))*int64(binary.Size(journal))
+	if err := Utilities.WriteObject(w.file, journal, position); err != nil {
+		return ioFailure(err, "No se pudo escribir el journaling")
+	}
+	return nil
+}
+
+// Funcion para obtener la ruta absoluta de una carpeta siguiendo las entradas ".."
+func (w *Writer) inodePath(index int32) (string, error) {
+	var names []string
+	for index != 0 {
+		parent, err := w.parentOf(index)
+		if err != nil {
+			return "", err
+		}
+
+		parentInode, err := ReadInode(w.file, w.sb, parent)
+		if err != nil {
+			return "", err
+		}
+		entries, err := ReadDirectory(w.file, w.sb, parentInode)
+		if err != nil {
+			return "", err
+		}
+		for _, entry := range entries {
+			if entry.B_inodo == index {
+				names = append([]string{ContentName(entry)}, names...)
+				break
+			}
+		}
+		index = parent
+	}
+	return "/" + strings.Join(names, "/"), nil
+}
+
+// Funcion para crear un inodo vacío con la fecha actual
+func newInode(uid int32, gid int32, inodeType byte, perm string) Structs.Inode {
+	inode := Structs.Inode{I_uid: uid, I_gid: gid}
+	date := now()
+	copy(inode.I_atime[:], date)
+	copy(inode.I_ctime[:], date)
+	copy(inode.I_mtime[:], date)
+	for i := range inode.I_block {
+		inode.I_block[i] = -1
+	}
+	inode.I_type[0] = inodeType
+	copy(inode.I_perm[:], perm)
+	return inode
+}
+
+func now() string {
+	return time.Now().Format("2006-01-02 15:04")
+}
+
+func firstFree(bitmap []byte) int32 {
+	for index, value := range bitmap {
+		if value == bitmapFree {
+			return int32(index)
+		}
+	}
+	return -1
+}
+
+func invalidName(name string) error {
+	return &Errors.DiskError{Kind: Errors.ErrInvalid, Code: "invalid_name", Message: fmt.Sprintf("Nombre inválido '%s', debe tener entre 1 y %d caracteres y no contener '/'", name, len(Structs.Content{}.B_name))}
+}
+
+func notFound(name string) error {
+	return &Errors.DiskError{Kind: Errors.ErrNotFound, Code: "path_not_found", Message: fmt.Sprintf("No existe una entrada con el nombre %s", name)}
+}
+
+func noSpace(message string) error {
+	return &Errors.DiskError{Kind: Errors.ErrNoSpace, Code: "filesystem_full", Message: message}
+}
+
+func ioFailure(err error, message string) error {
+	return &Errors.DiskError{Kind: Errors.ErrIO, Message: message, Err: err}
+}
+
+func corrupt(format string, args ...interface{}) error {
+	return &Errors.DiskError{Kind: Errors.ErrCorrupt, Message: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,384 @@
+package FileSystem
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"proyecto1/Errors"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+	"strings"
+	"testing"
+)
+
+// Contenido de users.txt en una partición recién formateada
+const testUsers = "1,G,root\n1,U,root,root,123\n"
+
+// Funcion para formatear una partición en memoria de size bytes como lo hace mkfs: raíz y users.txt
+func newTestPartition(t *testing.T, size int32, ext3 bool) (Utilities.BlockDevice, Structs.Partition) {
+	t.Helper()
+	partition := Structs.Partition{Start: 0, Size: size}
+	partition.Type[0] = 'p'
+	file := Utilities.NewMemoryDevice(int64(size))
+
+	var sb Structs.Superblock
+	superblockSize := int32(binary.Size(sb))
+	inodeSize := int32(binary.Size(Structs.Inode{}))
+	blockSize := int32(binary.Size(Structs.Fileblock{}))
+	journalSize := int32(0)
+	sb.S_filesystem_type = 2
+	if ext3 {
+		journalSize = int32(binary.Size(Structs.Journal{}))
+		sb.S_filesystem_type = 3
+	}
+	n := (size - superblockSize) / (4 + inodeSize + 3*blockSize + journalSize)
+
+	sb.S_inodes_count, sb.S_blocks_count = n, 3*n
+	sb.S_free_inodes_count, sb.S_free_blocks_count = n-2, 3*n-2
+	sb.S_mnt_count, sb.S_magic = 1, 0xEF53
+	sb.S_inode_size, sb.S_block_size = inodeSize, blockSize
+	sb.S_fist_ino, sb.S_first_blo = 2, 2
+	sb.S_bm_inode_start = partition.Start + superblockSize + journalSize*n
+	sb.S_bm_block_start = sb.S_bm_inode_start + n
+	sb.S_inode_start = sb.S_bm_block_start + 3*n
+	sb.S_block_start = sb.S_inode_start + n*inodeSize
+	copy(sb.S_mtime[:], now())
+	copy(sb.S_umtime[:], now())
+	if err := WriteSuperblock(file, partition, sb); err != nil {
+		t.Fatal(err)
+	}
+
+	mustWrite(t, file, []byte{bitmapUsed, bitmapUsed}, int64(sb.S_bm_inode_start))
+	mustWrite(t, file, []byte{bitmapUsed, bitmapUsed}, int64(sb.S_bm_block_start))
+
+	root := newInode(RootUID, 1, '0', "777")
+	root.I_block[0] = 0
+	users := newInode(RootUID, 1, '1', "664")
+	users.I_block[0] = 1
+	users.I_size = int32(len(testUsers))
+	mustWrite(t, file, root, int64(sb.S_inode_start))
+	mustWrite(t, file, users, int64(sb.S_inode_start+inodeSize))
+
+	var folder Structs.Folderblock
+	copy(folder.B_content[0].B_name[:], ".")
+	copy(folder.B_content[1].B_name[:], "..")
+	copy(folder.B_content[2].B_name[:], "users.txt")
+	folder.B_content[2].B_inodo = 1
+	folder.B_content[3].B_inodo = -1
+	mustWrite(t, file, folder, int64(sb.S_block_start))
+	var fileBlock Structs.Fileblock
+	copy(fileBlock.B_content[:], testUsers)
+	mustWrite(t, file, fileBlock, int64(sb.S_block_start+blockSize))
+	return file, partition
+}
+
+func mustWrite(t *testing.T, file Utilities.BlockDevice, data interface{}, position int64) {
+	t.Helper()
+	if err := Utilities.WriteObject(file, data, position); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Funcion para verificar que fsck no encuentre problemas en la partición
+func assertClean(t *testing.T, file Utilities.BlockDevice, partition Structs.Partition) {
+	t.Helper()
+	issues, err := CheckFileSystem(file, partition, false)
+	if err != nil {
+		t.Fatalf("CheckFileSystem: %v", err)
+	}
+	for _, issue := range issues {
+		t.Errorf("fsck: %s: %s", issue.Check, issue.Message)
+	}
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+	for _, ext3 := range []bool{false, true} {
+		file, partition := newTestPartition(t, 256*1024, ext3)
+		writer, err := NewWriter(file, partition)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		docs, err := writer.MkdirAll("/home/docs", RootUID, 1, "664")
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Un archivo que necesita bloques de apuntadores y muchas entradas que necesitan varios bloques de carpeta
+		big := strings.Repeat("0123456789abcdef", 200)
+		if _, err := writer.WriteFile(docs, "big.txt", []byte(big), RootUID, 1, "664"); err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+			if _, err := writer.WriteFile(docs, name+".txt", []byte(name), RootUID, 1, "664"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := writer.WriteFile(docs, "big.txt", []byte("corto"), RootUID, 1, "664"); err != nil {
+			t.Fatal(err)
+		}
+		home, err := SearchInode(file, writer.Superblock(), "/home")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.Rename(docs, "a.txt", home, "z.txt"); err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.Remove(docs, "b.txt"); err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		sb := writer.Superblock()
+		index, err := SearchInode(file, sb, "/home/docs/big.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		inode, err := ReadInode(file, sb, index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if content, _ := ReadFileContent(file, sb, inode); content != "corto" {
+			t.Errorf("contenido de big.txt = %q, se esperaba %q", content, "corto")
+		}
+		if _, err := SearchInode(file, sb, "/home/z.txt"); err != nil {
+			t.Errorf("z.txt no se movió: %v", err)
+		}
+		if _, err := SearchInode(file, sb, "/home/docs/b.txt"); !errors.Is(err, Errors.ErrNotFound) {
+			t.Errorf("b.txt sigue existiendo: %v", err)
+		}
+		assertClean(t, file, partition)
+	}
+}
+
+func TestImportNearlyFull(t *testing.T) {
+	src := t.TempDir()
+	for i, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		dir := filepath.Join(src, name)
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		content := strings.Repeat(name, 64*(i+1)*8)
+		if err := os.WriteFile(filepath.Join(dir, "data.txt"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// La partición alcanza para una parte de la carpeta, la copia debe fallar por espacio sin dejar nada a medias
+	file, partition := newTestPartition(t, 16*1024, false)
+	_, err := Import(file, partition, src, "/import", RootUID, 1)
+	if !errors.Is(err, Errors.ErrNoSpace) {
+		t.Fatalf("Import: se esperaba un error de espacio y se obtuvo %v", err)
+	}
+	assertClean(t, file, partition)
+
+	// Un archivo que no cabe no debe crearse ni reemplazar al existente
+	writer, err := NewWriter(file, partition)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.WriteFile(0, "users.txt", make([]byte, 64*1024), RootUID, 1, "664"); !errors.Is(err, Errors.ErrNoSpace) {
+		t.Fatalf("WriteFile: se esperaba un error de espacio y se obtuvo %v", err)
+	}
+	if _, err := writer.WriteFile(0, "nuevo.txt", make([]byte, 64*1024), RootUID, 1, "664"); !errors.Is(err, Errors.ErrNoSpace) {
+		t.Fatalf("WriteFile: se esperaba un error de espacio y se obtuvo %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sb := writer.Superblock()
+	if _, err := SearchInode(file, sb, "/nuevo.txt"); !errors.Is(err, Errors.ErrNotFound) {
+		t.Errorf("nuevo.txt se creó aunque no cabía: %v", err)
+	}
+	users, _ := ReadInode(file, sb, 1)
+	if content, _ := ReadFileContent(file, sb, users); content != testUsers {
+		t.Errorf("users.txt cambió: %q", content)
+	}
+	assertClean(t, file, partition)
+}
+
+func TestImportSkipsSpecialFiles(t *testing.T) {
+	src := t.TempDir()
+	if err := os.WriteFile(filepath.Join(src, "a.txt"), []byte("hola"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(src, "enlace")
+	if err := os.Symlink("a.txt", link); err != nil {
+		t.Skip("no se pueden crear enlaces:", err)
+	}
+
+	file, partition := newTestPartition(t, 64*1024, false)
+	result, err := Import(file, partition, src, "/import", RootUID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Files != 1 || len(result.Skipped) != 1 || !strings.HasPrefix(result.Skipped[0], link+":") {
+		t.Fatalf("se esperaba 1 archivo y %s omitido, se obtuvo %+v", link, result)
+	}
+	assertClean(t, file, partition)
+}
+
+func TestImportSkipsLongNames(t *testing.T) {
+	src := t.TempDir()
+	long := filepath.Join(src, "nombre-muy-largo")
+	if err := os.MkdirAll(long, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{filepath.Join(long, "b.txt"), filepath.Join(src, "archivo-largo.txt"), filepath.Join(src, "z.txt")} {
+		if err := os.WriteFile(name, []byte("hola"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// La copia sigue después de los nombres que no caben, z.txt queda después en el orden de ReadDir
+	file, partition := newTestPartition(t, 64*1024, false)
+	result, err := Import(file, partition, src, "/import", RootUID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Files != 1 || result.Directories != 0 || len(result.Skipped) != 2 {
+		t.Fatalf("se esperaba 1 archivo y 2 nombres omitidos, se obtuvo %+v", result)
+	}
+	sb, err := ReadSuperblock(file, partition)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SearchInode(file, sb, "/import/z.txt"); err != nil {
+		t.Fatal(err)
+	}
+	info, err := Stat(file, sb, "/import")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inode := info.Inode(); string(inode.I_perm[:]) != "775" {
+		t.Errorf("La carpeta destino tiene permisos %s, se esperaba 775", inode.I_perm[:])
+	}
+	assertClean(t, file, partition)
+}
+
+func TestWriteFileReplaceWithoutSpace(t *testing.T) {
+	file, partition := newTestPartition(t, 16*1024, false)
+	writer, err := NewWriter(file, partition)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := strings.Repeat("a", 640)
+	index, err := writer.WriteFile(0, "a.txt", []byte(original), RootUID, 1, "664")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Se llena el resto de la partición, el contenido nuevo solo cabría liberando antes el actual
+	for free := countFree(writer.blockBitmap); free > 0; free = countFree(writer.blockBitmap) {
+		if _, err := writer.allocBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := writer.WriteFile(0, "a.txt", []byte(strings.Repeat("b", 640)), RootUID, 1, "664"); !errors.Is(err, Errors.ErrNoSpace) {
+		t.Fatalf("WriteFile: se esperaba un error de espacio y se obtuvo %v", err)
+	}
+	inode, err := ReadInode(file, writer.Superblock(), index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := ReadFileContent(file, writer.Superblock(), inode); content != original {
+		t.Errorf("el contenido de a.txt cambió: %d bytes", len(content))
+	}
+}
+
+func TestCanRemoveChecksSubtree(t *testing.T) {
+	file, partition := newTestPartition(t, 64*1024, false)
+	writer, err := NewWriter(file, partition)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// La carpeta es del usuario, pero adentro hay un archivo de root que el usuario no puede escribir
+	dir, err := writer.Mkdir(0, "d", 2, 2, "775")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.WriteFile(dir, "mio.txt", []byte("hola"), 2, 2, "664"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.WriteFile(dir, "root.txt", []byte("hola"), RootUID, 1, "644"); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	sb := writer.Superblock()
+	user := User{UID: 2, GID: 2}
+	if err := user.CanRemove(file, sb, dir, "/d"); !errors.Is(err, Errors.ErrDenied) {
+		t.Fatalf("Se esperaba permiso denegado sobre /d/root.txt y se obtuvo %v", err)
+	}
+	if err := (User{UID: RootUID, GID: 1}).CanRemove(file, sb, dir, "/d"); err != nil {
+		t.Fatalf("Root debe poder eliminar /d: %v", err)
+	}
+}
+
+func TestRemoveRejectsOutOfRangeEntry(t *testing.T) {
+	file, partition := newTestPartition(t, 64*1024, false)
+	writer, err := NewWriter(file, partition)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := writer.Mkdir(0, "d", RootUID, 1, "775")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.WriteFile(dir, "f.txt", []byte("hola"), RootUID, 1, "664"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Se cambia el inodo de la entrada directamente en el bloque de carpeta, como en una imagen manipulada
+	sb := writer.Superblock()
+	inode, err := ReadInode(file, sb, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var folder Structs.Folderblock
+	if err := ReadBlock(file, sb, inode.I_block[0], &folder); err != nil {
+		t.Fatal(err)
+	}
+	folder.B_content[2].B_inodo = sb.S_inodes_count + 100
+	if err := writer.writeBlock(inode.I_block[0], folder); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writer.Remove(0, "d"); !errors.Is(err, Errors.ErrCorrupt) {
+		t.Fatalf("Se esperaba un error de estructura corrupta y se obtuvo %v", err)
+	}
+}
+
+func TestReadErrorKinds(t *testing.T) {
+	file, partition := newTestPartition(t, 64*1024, false)
+	sb, err := ReadSuperblock(file, partition)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := ReadInode(file, sb, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var folder Structs.Folderblock
+	tests := []struct {
+		name string
+		err  error
+		kind error
+	}{
+		{"inodo fuera de rango", func() error { _, err := ReadInode(file, sb, sb.S_inodes_count); return err }(), Errors.ErrCorrupt},
+		{"bloque fuera de rango", ReadBlock(file, sb, -2, &folder), Errors.ErrCorrupt},
+		{"superbloque fuera del disco", func() error {
+			_, err := ReadSuperblock(file, Structs.Partition{Start: 1 << 20})
+			return err
+		}(), Errors.ErrIO},
+		{"carpeta que es archivo", func() error { _, err := ReadFileBytes(file, sb, root); return err }(), Errors.ErrInvalid},
+	}
+	for _, test := range tests {
+		if !errors.Is(test.err, test.kind) {
+			t.Errorf("%s: se esperaba %v y se obtuvo %v", test.name, test.kind, test.err)
+		}
+	}
+}
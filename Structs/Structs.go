@@ -10,6 +10,7 @@ type MRB struct {
 	Signature    int32    // 4 bytes
 	Fit          [1]byte  // 1 byte
 	Partitions   [4]Partition
+	Checksum     uint32 // 4 bytes, CRC32 de los campos anteriores, debe ser el último campo
 }
 
 func PrintMBR(data MRB) {
@@ -41,6 +42,7 @@ type EBR struct {
 	PartSize  int32
 	PartNext  int32
 	PartName  [16]byte
+	Checksum  uint32 // CRC32 de los campos anteriores, debe ser el último campo
 }
 
 func PrintEBR(data EBR) {
@@ -73,6 +75,7 @@ type Superblock struct {
 	S_bm_block_start    int32    // Guardará el inicio del bitmap de bloques
 	S_inode_start       int32    // Guardará el inicio de la tabla de inodos
 	S_block_start       int32    // Guardará el inicio de la tabla de bloques
+	S_checksum          uint32   // CRC32 de los campos anteriores, debe ser el último campo
 }
 
 type Inode struct {
@@ -102,4 +105,18 @@ type Fileblock struct {
 
 type Pointerblock struct {
 	B_pointers [16]int32
-}
\ No newline at end of file
+}
+
+//Estructuras relacionadas a EXT3
+
+type Journal struct {
+	J_count   int32
+	J_content Information
+}
+
+type Information struct {
+	I_operation [10]byte
+	I_path      [32]byte
+	I_content   [64]byte
+	I_date      [17]byte
+}
@@ -0,0 +1,161 @@
+package Utilities
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Dispositivo de bloques sobre el que se leen y escriben las estructuras de un disco
+// Puede estar respaldado por un archivo del sistema o solo existir en memoria
+type BlockDevice interface {
+	ReadAt(p []byte, off int64) (int, error)
+	WriteAt(p []byte, off int64) (int, error)
+	Size() (int64, error)
+	Truncate(size int64) error
+	Sync() error
+	Close() error
+}
+
+// Dispositivo respaldado por un archivo del sistema
+type FileDevice struct {
+	*os.File
+}
+
+func (d *FileDevice) Size() (int64, error) {
+	info, err := d.File.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// Dispositivo que guarda el contenido del disco en memoria, se pierde al terminar la sesión
+type MemoryDevice struct {
+	mu   sync.RWMutex
+	data []byte
+}
+
+func NewMemoryDevice(size int64) *MemoryDevice {
+	return &MemoryDevice{data: make([]byte, size)}
+}
+
+func (d *MemoryDevice) ReadAt(p []byte, off int64) (int, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if off < 0 {
+		return 0, fmt.Errorf("Posición negativa %d", off)
+	}
+	if off >= int64(len(d.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, d.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (d *MemoryDevice) WriteAt(p []byte, off int64) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if off < 0 {
+		return 0, fmt.Errorf("Posición negativa %d", off)
+	}
+	// Igual que un archivo, escribir después del final hace crecer el dispositivo
+	if end := off + int64(len(p)); end > int64(len(d.data)) {
+		d.data = append(d.data, make([]byte, end-int64(len(d.data)))...)
+	}
+	return copy(d.data[off:], p), nil
+}
+
+func (d *MemoryDevice) Size() (int64, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return int64(len(d.data)), nil
+}
+
+func (d *MemoryDevice) Truncate(size int64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if size < 0 {
+		return fmt.Errorf("Tamaño negativo %d", size)
+	}
+	if size <= int64(len(d.data)) {
+		d.data = d.data[:size]
+	} else {
+		d.data = append(d.data, make([]byte, size-int64(len(d.data)))...)
+	}
+	return nil
+}
+
+func (d *MemoryDevice) Sync() error {
+	return nil
+}
+
+// Cerrar un dispositivo en memoria no libera su contenido, sigue registrado con su ruta
+func (d *MemoryDevice) Close() error {
+	return nil
+}
+
+// Registro de los discos en memoria, indexados por su ruta absoluta
+var (
+	memoryMode    bool
+	memoryDevices = make(map[string]*MemoryDevice)
+	memoryMutex   sync.Mutex
+)
+
+// Funcion para activar o desactivar los discos en memoria
+// Mientras está activo, CreateFile, OpenFile y DeleteFile no tocan el sistema de archivos del host
+func SetMemoryMode(enabled bool) {
+	memoryMutex.Lock()
+	defer memoryMutex.Unlock()
+	memoryMode = enabled
+	if !enabled {
+		memoryDevices = make(map[string]*MemoryDevice)
+	}
+}
+
+// Funcion para saber si los discos se están creando en memoria
+func MemoryMode() bool {
+	memoryMutex.Lock()
+	defer memoryMutex.Unlock()
+	return memoryMode
+}
+
+func memoryKey(name string) string {
+	if abs, err := filepath.Abs(name); err == nil {
+		return abs
+	}
+	return filepath.Clean(name)
+}
+
+func createMemoryDevice(name string) {
+	memoryMutex.Lock()
+	defer memoryMutex.Unlock()
+	if _, ok := memoryDevices[memoryKey(name)]; !ok {
+		memoryDevices[memoryKey(name)] = NewMemoryDevice(0)
+	}
+}
+
+func openMemoryDevice(name string) (BlockDevice, error) {
+	memoryMutex.Lock()
+	defer memoryMutex.Unlock()
+	device, ok := memoryDevices[memoryKey(name)]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	return device, nil
+}
+
+func deleteMemoryDevice(name string) error {
+	memoryMutex.Lock()
+	defer memoryMutex.Unlock()
+	if _, ok := memoryDevices[memoryKey(name)]; !ok {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+	delete(memoryDevices, memoryKey(name))
+	return nil
+}
@@ -0,0 +1,357 @@
+package Utilities
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+)
+
+// Tamaño de las páginas que guarda una vista copy-on-write
+const overlayPageSize = 4096
+
+// Vista copy-on-write de un dispositivo, las lecturas van al dispositivo original y las escrituras
+// se guardan en memoria por páginas, el original nunca se modifica
+// Si no hay dispositivo original la vista corresponde a un disco creado durante el dry-run
+// Del original solo se leen los primeros limit bytes, después de reducir la vista el resto se lee como ceros
+type OverlayDevice struct {
+	mu      sync.RWMutex
+	base    BlockDevice
+	size    int64
+	limit   int64
+	pages   map[int64][]byte
+	written int64
+}
+
+func NewOverlayDevice(base BlockDevice) (*OverlayDevice, error) {
+	overlay := &OverlayDevice{base: base, pages: make(map[int64][]byte)}
+	if base != nil {
+		size, err := base.Size()
+		if err != nil {
+			return nil, err
+		}
+		overlay.size, overlay.limit = size, size
+	}
+	return overlay, nil
+}
+
+func (d *OverlayDevice) ReadAt(p []byte, off int64) (int, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if off < 0 {
+		return 0, fmt.Errorf("Posición negativa %d", off)
+	}
+	if off >= d.size {
+		return 0, io.EOF
+	}
+
+	n := len(p)
+	if remaining := d.size - off; int64(n) > remaining {
+		n = int(remaining)
+	}
+	for read := 0; read < n; {
+		position := off + int64(read)
+		page, inPage := position/overlayPageSize, position%overlayPageSize
+		chunk := p[read:n]
+		if int64(len(chunk)) > overlayPageSize-inPage {
+			chunk = chunk[:overlayPageSize-inPage]
+		}
+		if err := d.readPage(page, inPage, chunk); err != nil {
+			return read, err
+		}
+		read += len(chunk)
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Funcion para leer parte de una página, de la copia en memoria si se modificó o del dispositivo original
+func (d *OverlayDevice) readPage(page int64, inPage int64, chunk []byte) error {
+	if data, ok := d.pages[page]; ok {
+		copy(chunk, data[inPage:])
+		return nil
+	}
+	for i := range chunk {
+		chunk[i] = 0
+	}
+	start := page*overlayPageSize + inPage
+	if d.base == nil || start >= d.limit {
+		return nil
+	}
+	if remaining := d.limit - start; int64(len(chunk)) > remaining {
+		chunk = chunk[:remaining]
+	}
+	if _, err := d.base.ReadAt(chunk, start); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+func (d *OverlayDevice) WriteAt(p []byte, off int64) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if off < 0 {
+		return 0, fmt.Errorf("Posición negativa %d", off)
+	}
+
+	for written := 0; written < len(p); {
+		position := off + int64(written)
+		page, inPage := position/overlayPageSize, position%overlayPageSize
+		chunk := p[written:]
+		if int64(len(chunk)) > overlayPageSize-inPage {
+			chunk = chunk[:overlayPageSize-inPage]
+		}
+		data, ok := d.pages[page]
+		if !ok {
+			// Una página fuera del original ya se lee como ceros, escribir ceros en ella no la cambia
+			// Así -prealloc o copiar un disco casi vacío no guarda en memoria todo el disco
+			if page*overlayPageSize >= d.limit && allZero(chunk) {
+				written += len(chunk)
+				continue
+			}
+			data = make([]byte, overlayPageSize)
+			if err := d.readPage(page, 0, data); err != nil {
+				return written, err
+			}
+			d.pages[page] = data
+		}
+		written += copy(data[inPage:], chunk)
+		d.written += int64(len(chunk))
+	}
+	// Igual que un archivo, escribir después del final hace crecer el dispositivo
+	if end := off + int64(len(p)); end > d.size {
+		d.size = end
+	}
+	return len(p), nil
+}
+
+func (d *OverlayDevice) Size() (int64, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.size, nil
+}
+
+// Al reducir el tamaño se descartan las páginas que quedan fuera, al crecer el espacio nuevo se lee como ceros
+func (d *OverlayDevice) Truncate(size int64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if size < 0 {
+		return fmt.Errorf("Tamaño negativo %d", size)
+	}
+	if size < d.size {
+		for page, data := range d.pages {
+			start := page * overlayPageSize
+			if start >= size {
+				delete(d.pages, page)
+			} else if start+overlayPageSize > size {
+				for i := size - start; i < overlayPageSize; i++ {
+					data[i] = 0
+				}
+			}
+		}
+	}
+	// Las partes del original después del tamaño más pequeño no deben reaparecer al crecer
+	if size < d.limit {
+		d.limit = size
+	}
+	d.size = size
+	return nil
+}
+
+func (d *OverlayDevice) Sync() error {
+	return nil
+}
+
+// Cerrar la vista no descarta los cambios, siguen registrados hasta terminar el dry-run
+func (d *OverlayDevice) Close() error {
+	return nil
+}
+
+// Funcion para que la vista tenga el contenido de otra vista del mismo tamaño sin leer todo el disco
+// Se comparte el dispositivo original de src y solo se copian sus páginas modificadas, así restaurar o clonar
+// un disco durante el dry-run no guarda en memoria el disco completo
+// El original no se modifica durante el dry-run, así los cambios de una vista no aparecen en la otra
+func (d *OverlayDevice) copyFrom(src *OverlayDevice) {
+	if d == src {
+		return
+	}
+	src.mu.RLock()
+	defer src.mu.RUnlock()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.base, d.limit, d.size = src.base, src.limit, src.size
+	d.pages = make(map[int64][]byte, len(src.pages))
+	for page, data := range src.pages {
+		d.pages[page] = append([]byte{}, data...)
+	}
+	d.written += src.size
+}
+
+// Funcion para saber si un bloque de bytes solo tiene ceros
+func allZero(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Cantidad de bytes que se escribieron en la vista, sin contar ceros escritos fuera del original
+func (d *OverlayDevice) BytesWritten() int64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.written
+}
+
+// Cambios de un disco durante un dry-run
+// Base es el disco original, nil si no existía, y Overlay la vista con los cambios
+type DryRunDisk struct {
+	Path    string
+	Created bool
+	Deleted bool
+	Base    BlockDevice
+	Overlay *OverlayDevice
+}
+
+// Estado del dry-run, mientras está activo CreateFile, OpenFile y DeleteFile trabajan sobre vistas copy-on-write
+var (
+	dryRun      bool
+	dryRunDisks = make(map[string]*DryRunDisk)
+	dryRunBases []BlockDevice
+	dryRunMutex sync.Mutex
+)
+
+// Funcion para iniciar un dry-run, devuelve false si ya había uno activo
+func BeginDryRun() bool {
+	dryRunMutex.Lock()
+	defer dryRunMutex.Unlock()
+	if dryRun {
+		return false
+	}
+	dryRun = true
+	dryRunDisks = make(map[string]*DryRunDisk)
+	return true
+}
+
+// Funcion para saber si hay un dry-run activo
+func DryRunActive() bool {
+	dryRunMutex.Lock()
+	defer dryRunMutex.Unlock()
+	return dryRun
+}
+
+// Funcion para terminar el dry-run, devuelve los discos que se crearon, abrieron o eliminaron ordenados por ruta
+// Los dispositivos originales siguen abiertos para comparar las vistas, se cierran con CloseDryRunBases
+func EndDryRun() []*DryRunDisk {
+	dryRunMutex.Lock()
+	defer dryRunMutex.Unlock()
+	dryRun = false
+
+	var disks []*DryRunDisk
+	for _, disk := range dryRunDisks {
+		disks = append(disks, disk)
+	}
+	sort.Slice(disks, func(i, j int) bool { return disks[i].Path < disks[j].Path })
+	dryRunDisks = make(map[string]*DryRunDisk)
+	return disks
+}
+
+// Funcion para cerrar los dispositivos originales abiertos durante el último dry-run
+func CloseDryRunBases() {
+	dryRunMutex.Lock()
+	defer dryRunMutex.Unlock()
+	for _, base := range dryRunBases {
+		base.Close()
+	}
+	dryRunBases = nil
+}
+
+// Funcion para crear un disco durante el dry-run, solo se registra una vista vacía
+func createDryRunDisk(name string) error {
+	dryRunMutex.Lock()
+	defer dryRunMutex.Unlock()
+	key := memoryKey(name)
+	disk, ok := dryRunDisks[key]
+	switch {
+	case ok && disk.Deleted:
+		// Un disco eliminado y creado de nuevo durante el dry-run empieza vacío, pero se compara con el original
+		disk.Deleted, disk.Overlay = false, &OverlayDevice{pages: make(map[int64][]byte)}
+	case !ok && !fileExists(name):
+		dryRunDisks[key] = &DryRunDisk{Path: name, Created: true, Overlay: &OverlayDevice{pages: make(map[int64][]byte)}}
+	}
+	// Igual que CreateFile, un disco que ya existe no se modifica
+	return nil
+}
+
+// Funcion para abrir la vista de un disco durante el dry-run, la primera vez se abre el original solo para lectura
+func openDryRunDisk(name string) (BlockDevice, error) {
+	dryRunMutex.Lock()
+	defer dryRunMutex.Unlock()
+	key := memoryKey(name)
+	if disk, ok := dryRunDisks[key]; ok {
+		if disk.Deleted {
+			return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+		}
+		return disk.Overlay, nil
+	}
+
+	base, err := openBase(name)
+	if err != nil {
+		return nil, err
+	}
+	overlay, err := NewOverlayDevice(base)
+	if err != nil {
+		base.Close()
+		return nil, err
+	}
+	dryRunBases = append(dryRunBases, base)
+	dryRunDisks[key] = &DryRunDisk{Path: name, Base: base, Overlay: overlay}
+	return overlay, nil
+}
+
+// Funcion para eliminar un disco durante el dry-run, solo se marca como eliminado
+func deleteDryRunDisk(name string) error {
+	if _, err := openDryRunDisk(name); err != nil {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+	dryRunMutex.Lock()
+	defer dryRunMutex.Unlock()
+	dryRunDisks[memoryKey(name)].Deleted = true
+	return nil
+}
+
+// Funcion para saber si un disco existe durante el dry-run
+func dryRunDiskExists(name string) bool {
+	dryRunMutex.Lock()
+	defer dryRunMutex.Unlock()
+	if disk, ok := dryRunDisks[memoryKey(name)]; ok {
+		return !disk.Deleted
+	}
+	return fileExists(name)
+}
+
+// Funcion para abrir solo para lectura el disco original, en memoria o en el sistema anfitrión
+func openBase(name string) (BlockDevice, error) {
+	if MemoryMode() {
+		return openMemoryDevice(name)
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &FileDevice{file}, nil
+}
+
+func fileExists(name string) bool {
+	if MemoryMode() {
+		_, err := openMemoryDevice(name)
+		return err == nil
+	}
+	_, err := os.Stat(name)
+	return err == nil
+}
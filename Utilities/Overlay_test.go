@@ -0,0 +1,159 @@
+package Utilities
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestOverlayZeroWrites(t *testing.T) {
+	// Un disco nuevo de 64 MB con -prealloc escribe ceros en todo el disco
+	overlay, err := NewOverlayDevice(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := int64(64 << 20)
+	if err := overlay.Truncate(size); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteZeros(overlay, size); err != nil {
+		t.Fatal(err)
+	}
+	if len(overlay.pages) != 0 || overlay.BytesWritten() != 0 {
+		t.Fatalf("escribir ceros guardó %d páginas y %d bytes", len(overlay.pages), overlay.BytesWritten())
+	}
+
+	// Los ceros sobre datos del original sí son un cambio
+	base := NewMemoryDevice(2 * overlayPageSize)
+	base.WriteAt(bytes.Repeat([]byte{7}, 2*overlayPageSize), 0)
+	overlay, err = NewOverlayDevice(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := overlay.WriteAt(make([]byte, 10), overlayPageSize-5); err != nil {
+		t.Fatal(err)
+	}
+	if len(overlay.pages) != 2 || overlay.BytesWritten() != 10 {
+		t.Fatalf("se esperaban 2 páginas y 10 bytes, hay %d y %d", len(overlay.pages), overlay.BytesWritten())
+	}
+}
+
+func TestOverlayPages(t *testing.T) {
+	base := NewMemoryDevice(3 * overlayPageSize)
+	original := make([]byte, 3*overlayPageSize)
+	for i := range original {
+		original[i] = byte(i%251 + 1)
+	}
+	base.WriteAt(original, 0)
+	overlay, err := NewOverlayDevice(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := append([]byte{}, original...)
+
+	// Una escritura que cruza dos páginas y otra que hace crecer la vista
+	data := bytes.Repeat([]byte{0xAA}, 100)
+	if _, err := overlay.WriteAt(data, overlayPageSize-50); err != nil {
+		t.Fatal(err)
+	}
+	copy(expected[overlayPageSize-50:], data)
+	if _, err := overlay.WriteAt([]byte("fin"), 3*overlayPageSize+10); err != nil {
+		t.Fatal(err)
+	}
+	expected = append(expected, make([]byte, 13)...)
+	copy(expected[3*overlayPageSize+10:], "fin")
+	assertOverlay(t, overlay, expected)
+
+	// Reducir y volver a crecer no debe mostrar otra vez el contenido que quedó fuera
+	shrink := int64(overlayPageSize + 20)
+	if err := overlay.Truncate(shrink); err != nil {
+		t.Fatal(err)
+	}
+	assertOverlay(t, overlay, expected[:shrink])
+	if err := overlay.Truncate(3 * overlayPageSize); err != nil {
+		t.Fatal(err)
+	}
+	expected = append(expected[:shrink], make([]byte, 3*overlayPageSize-shrink)...)
+	assertOverlay(t, overlay, expected)
+
+	// El original no se modifica
+	current := make([]byte, len(original))
+	base.ReadAt(current, 0)
+	if !bytes.Equal(current, original) {
+		t.Fatal("la vista modificó el dispositivo original")
+	}
+}
+
+// Funcion para comparar el contenido y el tamaño de la vista, leyendo en trozos que cruzan páginas
+func assertOverlay(t *testing.T, overlay *OverlayDevice, expected []byte) {
+	t.Helper()
+	if size, _ := overlay.Size(); size != int64(len(expected)) {
+		t.Fatalf("tamaño %d, se esperaba %d", size, len(expected))
+	}
+	content := make([]byte, 0, len(expected))
+	chunk := make([]byte, 1000)
+	for off := int64(0); off < int64(len(expected)); {
+		n, err := overlay.ReadAt(chunk, off)
+		if err != nil && err != io.EOF {
+			t.Fatal(err)
+		}
+		content = append(content, chunk[:n]...)
+		off += int64(n)
+	}
+	if !bytes.Equal(content, expected) {
+		t.Fatal("el contenido de la vista no es el esperado")
+	}
+}
+
+func TestOverlayCopyDevice(t *testing.T) {
+	// Restaurar un snapshot durante el dry-run no debe guardar en memoria el snapshot completo
+	size := int64(64 * overlayPageSize)
+	base := NewMemoryDevice(size)
+	original := make([]byte, size)
+	for i := range original {
+		original[i] = byte(i%251 + 1)
+	}
+	base.WriteAt(original, 0)
+	src, err := NewOverlayDevice(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.WriteAt([]byte("cambio"), 10); err != nil {
+		t.Fatal(err)
+	}
+	expected := append([]byte{}, original...)
+	copy(expected[10:], "cambio")
+
+	dst, err := NewOverlayDevice(NewMemoryDevice(2 * overlayPageSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dst.Truncate(size); err != nil {
+		t.Fatal(err)
+	}
+	if err := CopyDevice(dst, src, size); err != nil {
+		t.Fatal(err)
+	}
+	if len(dst.pages) != 1 {
+		t.Errorf("la copia guardó %d páginas, se esperaba solo la modificada", len(dst.pages))
+	}
+
+	// Las escrituras posteriores en una vista no aparecen en la otra
+	if _, err := src.WriteAt([]byte("después"), 5*overlayPageSize); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dst.WriteAt([]byte("destino"), 10); err != nil {
+		t.Fatal(err)
+	}
+	copy(expected[10:], "destino")
+	got := make([]byte, size)
+	if _, err := dst.ReadAt(got, 0); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, expected) {
+		t.Error("la copia no tiene el contenido de la vista original")
+	}
+	if _, err := src.ReadAt(got[:6], 10); err != nil || string(got[:6]) != "cambio" {
+		t.Errorf("la escritura en la copia cambió la vista original: %q", got[:6])
+	}
+}
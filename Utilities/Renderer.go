@@ -0,0 +1,408 @@
+package Utilities
+
+import (
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Funcion para escribir un reporte sin Graphviz, soporta svg, png y jpg
+func RenderReportBuiltin(report Report, outputPath string, format string) error {
+	switch format {
+	case "svg":
+		return os.WriteFile(outputPath, []byte(RenderSVG(report)), 0644)
+	case "png", "jpg":
+		img := RenderImage(report)
+		file, err := os.Create(outputPath)
+		if err != nil {
+			return fmt.Errorf("Error al crear el reporte %s: %v", outputPath, err)
+		}
+		defer file.Close()
+		if format == "png" {
+			err = png.Encode(file, img)
+		} else {
+			err = jpeg.Encode(file, img, &jpeg.Options{Quality: 95})
+		}
+		if err != nil {
+			return fmt.Errorf("Error al escribir el reporte %s: %v", outputPath, err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("El formato %s requiere Graphviz instalado", format)
+	}
+}
+
+// Elemento ya posicionado del reporte, lo comparten el renderizador SVG y el de imágenes
+type reportBox struct {
+	X, Y, W, H int
+	Fill       string
+	Text       string
+	TextColor  string
+	Bold       bool
+	Center     bool
+}
+
+// Medidas del texto usadas por cada renderizador
+type reportMetrics struct {
+	CharWidth  int
+	LineHeight int
+	Padding    int
+}
+
+var svgMetrics = reportMetrics{CharWidth: 8, LineHeight: 16, Padding: Theme.CellPadding}
+var imageMetrics = reportMetrics{CharWidth: 7, LineHeight: 15, Padding: Theme.CellPadding}
+
+func textWidth(text string, m reportMetrics) int {
+	width := 0
+	for _, line := range strings.Split(text, "\n") {
+		if w := utf8.RuneCountInString(line) * m.CharWidth; w > width {
+			width = w
+		}
+	}
+	return width + 2*m.Padding
+}
+
+func textHeight(text string, m reportMetrics) int {
+	return len(strings.Split(text, "\n"))*m.LineHeight + 2*m.Padding
+}
+
+// Funcion para calcular la posición de cada celda del reporte
+func layoutReport(report Report, m reportMetrics) ([]reportBox, int, int) {
+	if len(report.Segments) > 0 {
+		return layoutSegments(report, m)
+	}
+	return layoutTable(report, m)
+}
+
+func layoutTable(report Report, m reportMetrics) ([]reportBox, int, int) {
+	columns := report.ColumnCount()
+
+	// Ancho de cada columna según el texto más largo
+	widths := make([]int, columns)
+	measure := func(cells []string) {
+		for i, cell := range cells {
+			if w := textWidth(cell, m); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+	measure(report.Columns)
+	for _, row := range report.Rows {
+		if !row.Section {
+			measure(row.Cells)
+		}
+	}
+
+	// Los títulos y secciones ocupan todo el ancho, se reparte el espacio extra en la última columna
+	total := 0
+	for _, w := range widths {
+		total += w
+	}
+	wide := textWidth(report.Title, m)
+	for _, row := range report.Rows {
+		if row.Section && len(row.Cells) > 0 && textWidth(row.Cells[0], m) > wide {
+			wide = textWidth(row.Cells[0], m)
+		}
+	}
+	if wide > total {
+		widths[columns-1] += wide - total
+		total = wide
+	}
+
+	var boxes []reportBox
+	y := 0
+	fullRow := func(text string) {
+		h := textHeight(text, m)
+		boxes = append(boxes, reportBox{X: 0, Y: y, W: total, H: h, Fill: Theme.TitleBg, Text: text, TextColor: Theme.TitleFg, Bold: true, Center: true})
+		y += h
+	}
+	cellsRow := func(cells []string, header bool) {
+		h := m.LineHeight + 2*m.Padding
+		for _, cell := range cells {
+			if th := textHeight(cell, m); th > h {
+				h = th
+			}
+		}
+		x := 0
+		for i := 0; i < columns; i++ {
+			text := ""
+			if i < len(cells) {
+				text = cells[i]
+			}
+			box := reportBox{X: x, Y: y, W: widths[i], H: h, Fill: Theme.CellBg, Text: text, TextColor: Theme.CellFg}
+			if header || (report.KeyValue && i == 0) {
+				box.Fill = Theme.HeaderBg
+				box.TextColor = Theme.HeaderFg
+				box.Bold = true
+			}
+			boxes = append(boxes, box)
+			x += widths[i]
+		}
+		y += h
+	}
+
+	fullRow(report.Title)
+	if len(report.Columns) > 0 {
+		cellsRow(report.Columns, true)
+	}
+	for _, row := range report.Rows {
+		if row.Section {
+			fullRow(strings.Join(row.Cells, " "))
+		} else {
+			cellsRow(row.Cells, false)
+		}
+	}
+	return boxes, total, y
+}
+
+func layoutSegments(report Report, m reportMetrics) ([]reportBox, int, int) {
+	const scale = 8 // Pixeles por cada punto porcentual del disco
+
+	segmentWidth := func(segment ReportSegment) int {
+		w := int(segment.Percentage * scale)
+		if tw := textWidth(segment.Label, m); tw > w {
+			w = tw
+		}
+		return w
+	}
+
+	// Alto de las filas: la principal contiene el título de la extendida, la segunda a sus lógicas
+	top := m.LineHeight + 2*m.Padding
+	bottom := 0
+	for _, segment := range report.Segments {
+		for _, child := range segment.Children {
+			if h := textHeight(child.Label, m); h > bottom {
+				bottom = h
+			}
+		}
+		if len(segment.Children) == 0 {
+			if h := textHeight(segment.Label, m); h > top+bottom {
+				bottom = h - top
+			}
+		}
+	}
+
+	titleHeight := textHeight(report.Title, m)
+	var boxes []reportBox
+	x := 0
+	for _, segment := range report.Segments {
+		if len(segment.Children) == 0 {
+			w := segmentWidth(segment)
+			boxes = append(boxes, reportBox{X: x, Y: titleHeight, W: w, H: top + bottom, Fill: Theme.SegmentColor(segment.Kind), Text: segment.Label, TextColor: Theme.CellFg, Center: true})
+			x += w
+			continue
+		}
+
+		childX := x
+		for _, child := range segment.Children {
+			w := segmentWidth(child)
+			boxes = append(boxes, reportBox{X: childX, Y: titleHeight + top, W: w, H: bottom, Fill: Theme.SegmentColor(child.Kind), Text: child.Label, TextColor: Theme.CellFg, Center: true})
+			childX += w
+		}
+		w := childX - x
+		if sw := segmentWidth(ReportSegment{Label: segment.Label}); sw > w {
+			// Se estira la última lógica para que coincida con el ancho de la extendida
+			boxes[len(boxes)-1].W += sw - w
+			w = sw
+		}
+		boxes = append(boxes, reportBox{X: x, Y: titleHeight, W: w, H: top, Fill: Theme.SegmentColor(segment.Kind), Text: segment.Label, TextColor: Theme.CellFg, Bold: true, Center: true})
+		x += w
+	}
+
+	boxes = append(boxes, reportBox{X: 0, Y: 0, W: x, H: titleHeight, Fill: Theme.TableBg, Text: report.Title, TextColor: Theme.CellFg, Bold: true, Center: true})
+	return boxes, x, titleHeight + top + bottom
+}
+
+// Funcion para generar el contenido SVG de un reporte
+func RenderSVG(report Report) string {
+	m := svgMetrics
+	boxes, width, height := layoutReport(report, m)
+
+	content := fmt.Sprintf("<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n", width+2, height+2, width+2, height+2)
+	content += fmt.Sprintf("<rect width=\"100%%\" height=\"100%%\" fill=\"%s\"/>\n", Theme.TableBg)
+	content += "<g transform=\"translate(1,1)\" font-family=\"monospace\" font-size=\"13\">\n"
+	for _, box := range boxes {
+		content += fmt.Sprintf("<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"%s\" stroke=\"black\"/>\n", box.X, box.Y, box.W, box.H, box.Fill)
+
+		lines := strings.Split(box.Text, "\n")
+		textX, anchor := box.X+m.Padding, "start"
+		if box.Center {
+			textX, anchor = box.X+box.W/2, "middle"
+		}
+		weight := "normal"
+		if box.Bold {
+			weight = "bold"
+		}
+		startY := box.Y + (box.H-len(lines)*m.LineHeight)/2
+		for i, line := range lines {
+			baseline := startY + (i+1)*m.LineHeight - 4
+			content += fmt.Sprintf("<text x=\"%d\" y=\"%d\" text-anchor=\"%s\" font-weight=\"%s\" fill=\"%s\">%s</text>\n", textX, baseline, anchor, weight, box.TextColor, html.EscapeString(line))
+		}
+	}
+	content += "</g>\n</svg>\n"
+	return content
+}
+
+// Funcion para dibujar un reporte como imagen, se codifica luego como png o jpg
+func RenderImage(report Report) *image.RGBA {
+	m := imageMetrics
+	boxes, width, height := layoutReport(report, m)
+
+	img := image.NewRGBA(image.Rect(0, 0, width+1, height+1))
+	draw.Draw(img, img.Bounds(), image.NewUniform(parseColor(Theme.TableBg)), image.Point{}, draw.Src)
+
+	face := basicfont.Face7x13
+	for _, box := range boxes {
+		box.Text = asciiReplacer.Replace(box.Text)
+		rect := image.Rect(box.X, box.Y, box.X+box.W, box.Y+box.H)
+		draw.Draw(img, rect, image.NewUniform(parseColor(box.Fill)), image.Point{}, draw.Src)
+		drawBorder(img, rect)
+
+		lines := strings.Split(box.Text, "\n")
+		startY := box.Y + (box.H-len(lines)*m.LineHeight)/2
+		for i, line := range lines {
+			x := box.X + m.Padding
+			if box.Center {
+				x = box.X + (box.W-utf8.RuneCountInString(line)*m.CharWidth)/2
+			}
+			baseline := startY + (i+1)*m.LineHeight - 4
+			drawer := &font.Drawer{Dst: img, Src: image.NewUniform(parseColor(box.TextColor)), Face: face}
+			drawer.Dot = fixed.P(x, baseline)
+			drawer.DrawString(line)
+			if box.Bold {
+				drawer.Dot = fixed.P(x+1, baseline)
+				drawer.DrawString(line)
+			}
+		}
+	}
+	return img
+}
+
+// La fuente basicfont solo contiene caracteres ASCII, los acentos se reemplazan para las imágenes
+var asciiReplacer = strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "Á", "A", "É", "E", "Í", "I", "Ó", "O", "Ú", "U", "ñ", "n", "Ñ", "N")
+
+func drawBorder(img *image.RGBA, rect image.Rectangle) {
+	black := color.RGBA{0, 0, 0, 255}
+	for x := rect.Min.X; x <= rect.Max.X; x++ {
+		img.Set(x, rect.Min.Y, black)
+		img.Set(x, rect.Max.Y, black)
+	}
+	for y := rect.Min.Y; y <= rect.Max.Y; y++ {
+		img.Set(rect.Min.X, y, black)
+		img.Set(rect.Max.X, y, black)
+	}
+}
+
+// Funcion para convertir un color "#rrggbb" o "white"/"black" a color.RGBA
+func parseColor(value string) color.RGBA {
+	switch value {
+	case "white":
+		return color.RGBA{255, 255, 255, 255}
+	case "black":
+		return color.RGBA{0, 0, 0, 255}
+	}
+	rgb, err := strconv.ParseUint(strings.TrimPrefix(value, "#"), 16, 32)
+	if err != nil {
+		return color.RGBA{0, 0, 0, 255}
+	}
+	return color.RGBA{uint8(rgb >> 16), uint8(rgb >> 8), uint8(rgb), 255}
+}
+
+// Funcion para generar un reporte como texto plano
+// Los reportes tabulares se escriben como una tabla con columnas alineadas y el reporte disk como una lista
+// de segmentos, con las lógicas dentro de su extendida
+func RenderText(report Report) string {
+	var b strings.Builder
+	b.WriteString(textCell(report.Title) + "\n")
+	if len(report.Segments) > 0 {
+		var writeSegments func(segments []ReportSegment, indent string)
+		writeSegments = func(segments []ReportSegment, indent string) {
+			for _, segment := range segments {
+				fmt.Fprintf(&b, "%s- %s\n", indent, textCell(segment.Label))
+				writeSegments(segment.Children, indent+"  ")
+			}
+		}
+		writeSegments(report.Segments, "")
+		return b.String()
+	}
+
+	// Ancho de cada columna según el texto más largo, las secciones estiran la última columna como en las imágenes
+	columns := report.ColumnCount()
+	widths := make([]int, columns)
+	measure := func(cells []string) {
+		for i, cell := range cells {
+			if w := utf8.RuneCountInString(textCell(cell)); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+	measure(report.Columns)
+	for _, row := range report.Rows {
+		if !row.Section {
+			measure(row.Cells)
+		}
+	}
+	total := 3 * (columns - 1)
+	for _, w := range widths {
+		total += w
+	}
+	for _, row := range report.Rows {
+		if w := utf8.RuneCountInString(textCell(strings.Join(row.Cells, " "))); row.Section && w > total {
+			widths[columns-1] += w - total
+			total = w
+		}
+	}
+
+	pad := func(text string, width int) string {
+		return text + strings.Repeat(" ", width-utf8.RuneCountInString(text))
+	}
+	border := "+"
+	for _, w := range widths {
+		border += strings.Repeat("-", w+2) + "+"
+	}
+	cellsRow := func(cells []string) {
+		b.WriteString("|")
+		for i := 0; i < columns; i++ {
+			text := ""
+			if i < len(cells) {
+				text = textCell(cells[i])
+			}
+			b.WriteString(" " + pad(text, widths[i]) + " |")
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(border + "\n")
+	if len(report.Columns) > 0 {
+		cellsRow(report.Columns)
+		b.WriteString(border + "\n")
+	}
+	for _, row := range report.Rows {
+		if row.Section {
+			b.WriteString(border + "\n")
+			b.WriteString("| " + pad(textCell(strings.Join(row.Cells, " ")), total) + " |\n")
+			b.WriteString(border + "\n")
+		} else {
+			cellsRow(row.Cells)
+		}
+	}
+	b.WriteString(border + "\n")
+	return b.String()
+}
+
+// Funcion para escribir en una sola línea el texto de una celda o segmento
+func textCell(text string) string {
+	return strings.ReplaceAll(text, "\n", " ")
+}
@@ -0,0 +1,60 @@
+package Utilities
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderReportFiles(t *testing.T) {
+	report := Report{Title: "Reporte de prueba", KeyValue: true}
+	report.Field("mbr_tamano", "1048576")
+	report.Section("Particion 1")
+	report.Field("part_name", "p1")
+
+	dir := t.TempDir()
+	txt := filepath.Join(dir, "reporte.txt")
+	if err := RenderReport(report, txt); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(txt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"Reporte de prueba", "| mbr_tamano | 1048576 |", "| Particion 1          |", "| part_name  | p1      |"} {
+		if !strings.Contains(string(content), line+"\n") {
+			t.Errorf("El reporte de texto no contiene %q:\n%s", line, content)
+		}
+	}
+	if strings.Contains(string(content), "digraph") {
+		t.Errorf("El reporte de texto contiene el .dot:\n%s", content)
+	}
+
+	// Con o sin Graphviz solo debe quedar el archivo pedido junto al reporte
+	if err := RenderReport(report, filepath.Join(dir, "reporte.svg")); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if strings.Join(names, ",") != "reporte.svg,reporte.txt" {
+		t.Errorf("Se esperaba solo reporte.svg y reporte.txt, la carpeta tiene %v", names)
+	}
+}
+
+func TestRenderTextSegments(t *testing.T) {
+	report := Report{Title: "disco.mia", Segments: []ReportSegment{
+		{Label: "MBR (168 bytes)"},
+		{Label: "Extendida", Children: []ReportSegment{{Label: "EBR (36 bytes)"}, {Label: "Libre\n10.00% del disco"}}},
+	}}
+	expected := "disco.mia\n- MBR (168 bytes)\n- Extendida\n  - EBR (36 bytes)\n  - Libre 10.00% del disco\n"
+	if text := RenderText(report); text != expected {
+		t.Errorf("Se obtuvo:\n%s\nse esperaba:\n%s", text, expected)
+	}
+}
@@ -0,0 +1,209 @@
+package Utilities
+
+import (
+	"encoding/binary"
+	"fmt"
+	"proyecto1/Structs"
+	"strings"
+)
+
+// Estructura genérica de un reporte, a partir de ella se generan el .dot y las imágenes del renderizador interno
+// Los reportes tabulares usan Columns y Rows, el reporte disk usa Segments
+type Report struct {
+	Title    string
+	KeyValue bool // La primera celda de cada fila es el nombre del campo (reportes mbr y sb)
+	Columns  []string
+	Rows     []ReportRow
+	Segments []ReportSegment
+}
+
+// Fila de un reporte tabular, las filas Section ocupan todo el ancho de la tabla
+type ReportRow struct {
+	Cells   []string
+	Section bool
+}
+
+// Segmento del reporte disk, las particiones extendidas contienen a sus lógicas en Children
+// Kind indica el color del tema: mbr, primaria, extendida, logica o libre
+type ReportSegment struct {
+	Label      string
+	Kind       string
+	Percentage float64
+	Children   []ReportSegment
+}
+
+// Funcion para agregar una fila campo/valor
+func (r *Report) Field(name string, value string) {
+	r.Rows = append(r.Rows, ReportRow{Cells: []string{name, value}})
+}
+
+// Funcion para agregar una fila de sección que ocupa todo el ancho
+func (r *Report) Section(title string) {
+	r.Rows = append(r.Rows, ReportRow{Cells: []string{title}, Section: true})
+}
+
+// Funcion para agregar una fila con un valor por columna
+func (r *Report) Row(cells ...string) {
+	r.Rows = append(r.Rows, ReportRow{Cells: cells})
+}
+
+// Funcion para obtener el número de columnas de la tabla
+func (r Report) ColumnCount() int {
+	columns := len(r.Columns)
+	for _, row := range r.Rows {
+		if !row.Section && len(row.Cells) > columns {
+			columns = len(row.Cells)
+		}
+	}
+	if columns == 0 {
+		columns = 1
+	}
+	return columns
+}
+
+func BuildReportMBR(mbr Structs.MRB, ebrs []Structs.EBR) Report {
+	report := Report{Title: "Reporte del MBR", KeyValue: true}
+	report.Field("MBR Tamaño", fmt.Sprintf("%d", mbr.MbrSize))
+	report.Field("MBR Fecha de Creación", string(mbr.CreationDate[:]))
+	report.Field("MBR Signature", fmt.Sprintf("%d", mbr.Signature))
+	report.Field("MBR Checksum", fmt.Sprintf("0x%08X", mbr.Checksum))
+
+	for _, part := range mbr.Partitions {
+		if part.Size <= 0 {
+			continue
+		}
+		partName := strings.TrimRight(string(part.Name[:]), "\x00")
+		report.Section("Partición " + partName)
+		report.Field("Status", string(part.Status[:]))
+		report.Field("Type", string(part.Type[:]))
+		report.Field("Fit", string(part.Fit[:]))
+		report.Field("Start", fmt.Sprintf("%d", part.Start))
+		report.Field("Size", fmt.Sprintf("%d", part.Size))
+		report.Field("Name", partName)
+
+		if string(part.Type[:]) == "e" {
+			for j, ebr := range ebrs {
+				report.Section(fmt.Sprintf("Particion logica %d", j+1))
+				report.Field("Part_status", "0")
+				report.Field("Part_next", fmt.Sprintf("%d", ebr.PartNext))
+				report.Field("Part_fit", string(ebr.PartFit))
+				report.Field("Part_start", fmt.Sprintf("%d", ebr.PartStart))
+				report.Field("Part_size", fmt.Sprintf("%d", ebr.PartSize))
+				report.Field("Part_name", strings.TrimRight(string(ebr.PartName[:]), "\x00"))
+			}
+		}
+	}
+	return report
+}
+
+func BuildReportDisk(mbr Structs.MRB, ebrs []Structs.EBR, totalDiskSize int32, fileName string) Report {
+	report := Report{Title: fileName}
+	percentage := func(size int32) float64 {
+		return float64(size) / float64(totalDiskSize) * 100
+	}
+
+	usedSpace := int32(binary.Size(mbr)) // Tamaño del MBR en bytes
+	ebrSize := int32(binary.Size(Structs.EBR{}))
+	report.Segments = append(report.Segments, ReportSegment{Label: fmt.Sprintf("MBR (%d bytes)", usedSpace), Kind: "mbr", Percentage: percentage(usedSpace)})
+
+	for _, part := range mbr.Partitions {
+		if part.Size <= 0 {
+			continue
+		}
+		partName := strings.TrimRight(string(part.Name[:]), "\x00")
+
+		if string(part.Type[:]) == "p" {
+			report.Segments = append(report.Segments, ReportSegment{
+				Label:      fmt.Sprintf("Primaria\n%s\n%.2f%% del disco", partName, percentage(part.Size)),
+				Kind:       "primaria",
+				Percentage: percentage(part.Size),
+			})
+			usedSpace += part.Size
+		} else if string(part.Type[:]) == "e" {
+			extended := ReportSegment{Label: "Extendida", Kind: "extendida", Percentage: percentage(part.Size)}
+			var usedExtended int32
+			for _, ebr := range ebrs {
+				extended.Children = append(extended.Children,
+					ReportSegment{Label: fmt.Sprintf("EBR (%d bytes)", ebrSize), Kind: "logica", Percentage: percentage(ebrSize)},
+					ReportSegment{Label: fmt.Sprintf("Lógica\n%.2f%% del disco", percentage(ebr.PartSize)), Kind: "logica", Percentage: percentage(ebr.PartSize)},
+				)
+				usedExtended += ebr.PartSize + ebrSize
+			}
+			freeExtended := part.Size - usedExtended
+			extended.Children = append(extended.Children, ReportSegment{
+				Label:      fmt.Sprintf("Libre\n%.2f%% del disco", percentage(freeExtended)),
+				Kind:       "libre",
+				Percentage: percentage(freeExtended),
+			})
+			report.Segments = append(report.Segments, extended)
+			usedSpace += part.Size
+		}
+	}
+
+	freeSpace := totalDiskSize - usedSpace
+	report.Segments = append(report.Segments, ReportSegment{
+		Label:      fmt.Sprintf("Libre\n%.2f%% del disco", percentage(freeSpace)),
+		Kind:       "libre",
+		Percentage: percentage(freeSpace),
+	})
+	return report
+}
+
+func BuildReportSB(sb Structs.Superblock) Report {
+	report := Report{Title: "Reporte de SUPERBLOQUE", KeyValue: true}
+	report.Field("S_filesystem_type", fmt.Sprintf("%d", sb.S_filesystem_type))
+	report.Field("S_inodes_count", fmt.Sprintf("%d", sb.S_inodes_count))
+	report.Field("S_blocks_count", fmt.Sprintf("%d", sb.S_blocks_count))
+	report.Field("S_free_blocks_count", fmt.Sprintf("%d", sb.S_free_blocks_count))
+	report.Field("S_free_inodes_count", fmt.Sprintf("%d", sb.S_free_inodes_count))
+	report.Field("S_mtime", strings.TrimRight(string(sb.S_mtime[:]), "\x00"))
+	report.Field("S_umtime", strings.TrimRight(string(sb.S_umtime[:]), "\x00"))
+	report.Field("S_mnt_count", fmt.Sprintf("%d", sb.S_mnt_count))
+	report.Field("S_magic", fmt.Sprintf("0x%X", sb.S_magic))
+	report.Field("S_inode_size", fmt.Sprintf("%d", sb.S_inode_size))
+	report.Field("S_block_size", fmt.Sprintf("%d", sb.S_block_size))
+	report.Field("S_first_ino", fmt.Sprintf("%d", sb.S_fist_ino))
+	report.Field("S_first_blo", fmt.Sprintf("%d", sb.S_first_blo))
+	report.Field("S_bm_inode_start", fmt.Sprintf("%d", sb.S_bm_inode_start))
+	report.Field("S_bm_block_start", fmt.Sprintf("%d", sb.S_bm_block_start))
+	report.Field("S_inode_start", fmt.Sprintf("%d", sb.S_inode_start))
+	report.Field("S_block_start", fmt.Sprintf("%d", sb.S_block_start))
+	report.Field("S_checksum", fmt.Sprintf("0x%08X", sb.S_checksum))
+	return report
+}
+
+// Estructura para representar una fila del reporte ls
+type LsEntry struct {
+	Permissions string `json:"permissions"`
+	Owner       string `json:"owner"`
+	Group       string `json:"group"`
+	Size        int32  `json:"size"`
+	Date        string `json:"date"`
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+}
+
+func BuildReportLs(entries []LsEntry, dirPath string) Report {
+	report := Report{Title: "Reporte LS de " + dirPath}
+	report.Columns = []string{"Permisos", "Owner", "Grupo", "Size (en Bytes)", "Fecha", "Tipo", "Name"}
+	for _, entry := range entries {
+		report.Row(entry.Permissions, entry.Owner, entry.Group, fmt.Sprintf("%d", entry.Size), entry.Date, entry.Type, entry.Name)
+	}
+	return report
+}
+
+func BuildReportJournaling(entries []Structs.Journal) Report {
+	report := Report{Title: "Reporte de Journaling"}
+	report.Columns = []string{"#", "Operación", "Path", "Contenido", "Fecha"}
+	for i, entry := range entries {
+		info := entry.J_content
+		report.Row(
+			fmt.Sprintf("%d", i+1),
+			strings.TrimRight(string(info.I_operation[:]), "\x00"),
+			strings.TrimRight(string(info.I_path[:]), "\x00"),
+			strings.TrimRight(string(info.I_content[:]), "\x00"),
+			strings.TrimRight(string(info.I_date[:]), "\x00"),
+		)
+	}
+	return report
+}
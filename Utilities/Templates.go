@@ -0,0 +1,152 @@
+package Utilities
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// Tema de los reportes, lo usan tanto las plantillas .dot como el renderizador interno
+type ReportTheme struct {
+	FontName    string
+	CellPadding int
+	TableBg     string
+	TitleBg     string
+	TitleFg     string
+	HeaderBg    string
+	HeaderFg    string
+	CellBg      string
+	CellFg      string
+	Segments    map[string]string // Color de cada tipo de segmento del reporte disk
+}
+
+// Tema con el que se generan todos los reportes, cambiarlo aquí cambia todos los reportes
+var Theme = ReportTheme{
+	FontName:    "Helvetica",
+	CellPadding: 10,
+	TableBg:     "#f7f7f7",
+	TitleBg:     "#003366",
+	TitleFg:     "white",
+	HeaderBg:    "#1e90ff",
+	HeaderFg:    "white",
+	CellBg:      "#87cefa",
+	CellFg:      "black",
+	Segments: map[string]string{
+		"mbr":       "#ADD8E6",
+		"primaria":  "#90EE90",
+		"extendida": "#FFD700",
+		"logica":    "#FFB6C1",
+		"libre":     "#D3D3D3",
+	},
+}
+
+// Funcion para obtener el color de un segmento del reporte disk
+func (t ReportTheme) SegmentColor(kind string) string {
+	if color, ok := t.Segments[kind]; ok {
+		return color
+	}
+	return t.CellBg
+}
+
+var templateFuncs = template.FuncMap{
+	// Escapa el texto y convierte los saltos de línea en <br/> para las etiquetas HTML de Graphviz
+	"label": func(text string) string {
+		return strings.ReplaceAll(html.EscapeString(text), "\n", "<br/>")
+	},
+}
+
+// Plantilla para los reportes tabulares (mbr, sb, ls, journaling)
+var tableTemplate = template.Must(template.New("table").Funcs(templateFuncs).Parse(`digraph G {
+	node [shape=none, margin=0, fontname="{{.Theme.FontName}}"]
+tabla1 [label=<
+<table border="0" cellborder="1" cellspacing="0" cellpadding="{{.Theme.CellPadding}}" bgcolor="{{.Theme.TableBg}}">
+<tr>
+<td bgcolor="{{.Theme.TitleBg}}" colspan="{{.Report.ColumnCount}}" align="center"><font color="{{.Theme.TitleFg}}"><b>{{label .Report.Title}}</b></font></td>
+</tr>
+{{- if .Report.Columns}}
+<tr>
+{{- range .Report.Columns}}
+<td bgcolor="{{$.Theme.HeaderBg}}" align="left"><font color="{{$.Theme.HeaderFg}}"><b>{{label .}}</b></font></td>
+{{- end}}
+</tr>
+{{- end}}
+{{- range .Report.Rows}}
+<tr>
+{{- if .Section}}
+<td bgcolor="{{$.Theme.TitleBg}}" colspan="{{$.Report.ColumnCount}}" align="center"><font color="{{$.Theme.TitleFg}}"><b>{{range .Cells}}{{label .}}{{end}}</b></font></td>
+{{- else}}
+{{- range $i, $cell := .Cells}}
+{{- if and $.Report.KeyValue (eq $i 0)}}
+<td bgcolor="{{$.Theme.HeaderBg}}" align="left"><font color="{{$.Theme.HeaderFg}}"><b>{{label $cell}}</b></font></td>
+{{- else}}
+<td bgcolor="{{$.Theme.CellBg}}" align="left"><font color="{{$.Theme.CellFg}}">{{label $cell}}</font></td>
+{{- end}}
+{{- end}}
+{{- end}}
+</tr>
+{{- end}}
+</table>
+>];
+}
+`))
+
+// Plantilla para el reporte disk, las extendidas se dibujan como una tabla anidada con sus lógicas
+var segmentsTemplate = template.Must(template.New("segments").Funcs(templateFuncs).Parse(`digraph G {
+	node [shape=none, fontname="{{.Theme.FontName}}"];
+	graph [splines=false];
+	subgraph cluster_disk {
+		label=<<b>{{label .Report.Title}}</b>>;
+		style=rounded;
+		color=black;
+		table [label=<
+			<TABLE BORDER="1" CELLBORDER="2" CELLSPACING="0" CELLPADDING="15" BGCOLOR="{{.Theme.TableBg}}">
+			<TR>
+{{- range .Report.Segments}}
+{{- if .Children}}
+			<TD BGCOLOR="{{$.Theme.SegmentColor .Kind}}">
+				<TABLE BORDER="0" CELLBORDER="1" CELLSPACING="0" CELLPADDING="{{$.Theme.CellPadding}}">
+				<TR><TD COLSPAN="{{len .Children}}"><b>{{label .Label}}</b></TD></TR>
+				<TR>
+{{- range .Children}}
+				<TD BGCOLOR="{{$.Theme.SegmentColor .Kind}}">{{label .Label}}</TD>
+{{- end}}
+				</TR>
+				</TABLE>
+			</TD>
+{{- else}}
+			<TD BGCOLOR="{{$.Theme.SegmentColor .Kind}}">{{label .Label}}</TD>
+{{- end}}
+{{- end}}
+			</TR>
+			</TABLE>
+>];
+	}
+}
+`))
+
+// Funcion para escribir el archivo .dot de un reporte en un archivo temporal, devuelve la ruta del .dot
+// Quien la llama debe eliminar el archivo al terminar
+func GenerateReportDot(report Report) (string, error) {
+	fileDot, err := os.CreateTemp("", "reporte-*.dot")
+	if err != nil {
+		return "", fmt.Errorf("Error al crear el archivo .dot de reporte: %v", err)
+	}
+	defer fileDot.Close()
+
+	tmpl := tableTemplate
+	if len(report.Segments) > 0 {
+		tmpl = segmentsTemplate
+	}
+
+	data := struct {
+		Report Report
+		Theme  ReportTheme
+	}{report, Theme}
+	if err := tmpl.Execute(fileDot, data); err != nil {
+		os.Remove(fileDot.Name())
+		return "", fmt.Errorf("Error al escribir en el archivo .dot: %v", err)
+	}
+	return fileDot.Name(), nil
+}
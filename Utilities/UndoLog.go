@@ -0,0 +1,247 @@
+package Utilities
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Cantidad máxima de comandos y de bytes (sumando los anteriores y los nuevos) que se guardan por disco
+// Al pasarse se descartan los comandos más antiguos, un comando que por sí solo se pasa no se puede deshacer
+// El registro solo existe en memoria, se pierde al reiniciar el servidor
+const (
+	undoLimit  = 64
+	undoBudget = 16 * 1024 * 1024
+)
+
+// Escritura de WriteObject, con los bytes que había antes y los que se escribieron
+type UndoWrite struct {
+	Offset int64
+	Old    []byte
+	New    []byte
+}
+
+// Escrituras que hizo un comando en un disco, en el orden en que se hicieron
+type UndoEntry struct {
+	Command string
+	Writes  []UndoWrite
+}
+
+// Error cuando el contenido actual de un disco no coincide con lo que escribió el comando que se quiere deshacer
+var ErrUndoConflict = errors.New("el disco cambió después del comando")
+
+// Comando que se está registrando, previous es el comando que lo ejecutó (por ejemplo execute)
+// reset son los discos que se escribieron sin WriteObject o se truncaron, sus escrituras ya no se pueden deshacer
+type undoRecording struct {
+	command  string
+	writes   map[string][]UndoWrite
+	size     map[string]int
+	reset    map[string]bool
+	previous *undoRecording
+}
+
+// Registro para deshacer de cada disco, indexado igual que los discos en memoria
+var (
+	undoLogs    = make(map[string][]UndoEntry)
+	undoCurrent *undoRecording
+	undoMutex   sync.Mutex
+)
+
+// Dispositivo abierto con OpenFile, registra las escrituras de WriteObject para poder deshacerlas
+// Las demás escrituras (WriteAt directo, Truncate) descartan el registro del disco
+type loggedDevice struct {
+	BlockDevice
+	key string
+}
+
+func (d *loggedDevice) WriteAt(p []byte, off int64) (int, error) {
+	resetUndo(d.key)
+	return d.BlockDevice.WriteAt(p, off)
+}
+
+func (d *loggedDevice) Truncate(size int64) error {
+	resetUndo(d.key)
+	return d.BlockDevice.Truncate(size)
+}
+
+// Funcion para escribir guardando los bytes que se reemplazan, fuera de un comando la escritura descarta el registro
+func (d *loggedDevice) writeLogged(p []byte, off int64) (int, error) {
+	old := make([]byte, len(p))
+	if _, err := d.BlockDevice.ReadAt(old, off); err != nil && err != io.EOF {
+		return 0, err
+	}
+	recordUndo(d.key, UndoWrite{Offset: off, Old: old, New: append([]byte{}, p...)})
+	return d.BlockDevice.WriteAt(p, off)
+}
+
+// Funcion para empezar a registrar las escrituras de un comando
+// Si ya se está registrando otro (un script de execute), sus escrituras se registran aparte
+func BeginUndo(command string) {
+	undoMutex.Lock()
+	defer undoMutex.Unlock()
+	undoCurrent = &undoRecording{
+		command:  command,
+		writes:   make(map[string][]UndoWrite),
+		size:     make(map[string]int),
+		reset:    make(map[string]bool),
+		previous: undoCurrent,
+	}
+}
+
+// Funcion para terminar el registro del comando actual, sus escrituras se agregan al registro de cada disco
+// También se guardan las de un comando que falló, para poder deshacer lo que alcanzó a escribir
+func EndUndo() {
+	undoMutex.Lock()
+	defer undoMutex.Unlock()
+	if undoCurrent == nil {
+		return
+	}
+	for key, writes := range undoCurrent.writes {
+		entries := append(undoLogs[key], UndoEntry{Command: undoCurrent.command, Writes: writes})
+		if len(entries) > undoLimit {
+			entries = entries[len(entries)-undoLimit:]
+		}
+		total := 0
+		for _, entry := range entries {
+			total += entry.size()
+		}
+		for total > undoBudget {
+			total -= entries[0].size()
+			entries = entries[1:]
+		}
+		undoLogs[key] = entries
+	}
+	undoCurrent = undoCurrent.previous
+}
+
+func recordUndo(key string, write UndoWrite) {
+	undoMutex.Lock()
+	defer undoMutex.Unlock()
+	if undoCurrent == nil {
+		discardUndo(key)
+		return
+	}
+	if undoCurrent.reset[key] {
+		return
+	}
+	// Un comando que se pasa del límite no se puede deshacer, y los anteriores tampoco porque el disco cambió
+	undoCurrent.size[key] += len(write.Old) + len(write.New)
+	if undoCurrent.size[key] > undoBudget {
+		discardUndo(key)
+		return
+	}
+	undoCurrent.writes[key] = append(undoCurrent.writes[key], write)
+}
+
+// Bytes que ocupa un comando en el registro
+func (e UndoEntry) size() int {
+	total := 0
+	for _, write := range e.Writes {
+		total += len(write.Old) + len(write.New)
+	}
+	return total
+}
+
+func resetUndo(key string) {
+	undoMutex.Lock()
+	defer undoMutex.Unlock()
+	discardUndo(key)
+}
+
+// Funcion para descartar el registro de un disco, incluyendo lo que llevan los comandos en curso
+// Quien la llama debe tener undoMutex
+func discardUndo(key string) {
+	delete(undoLogs, key)
+	for recording := undoCurrent; recording != nil; recording = recording.previous {
+		delete(recording.writes, key)
+		recording.reset[key] = true
+	}
+}
+
+// Funcion para obtener los comandos que se pueden deshacer en un disco, del más antiguo al más reciente
+func UndoEntries(name string) []UndoEntry {
+	undoMutex.Lock()
+	defer undoMutex.Unlock()
+	return append([]UndoEntry{}, undoLogs[memoryKey(name)]...)
+}
+
+// Funcion para deshacer los últimos steps comandos de un disco, del más reciente al más antiguo
+// Antes de deshacer un comando se verifica que el disco todavía tenga lo que escribió, si no se detiene con ErrUndoConflict
+// Devuelve los comandos que se deshicieron, durante un dry-run se deshacen en la vista y el registro no cambia
+func ApplyUndo(name string, steps int) ([]UndoEntry, error) {
+	var file BlockDevice
+	var err error
+	if DryRunActive() {
+		file, err = OpenFile(name)
+	} else {
+		file, err = openRaw(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	key := memoryKey(name)
+	entries := UndoEntries(name)
+	var undone []UndoEntry
+	for len(undone) < steps && len(entries) > 0 {
+		entry := entries[len(entries)-1]
+		if err := verifyUndo(file, entry); err != nil {
+			return undone, err
+		}
+		for i := len(entry.Writes) - 1; i >= 0; i-- {
+			if _, err := file.WriteAt(entry.Writes[i].Old, entry.Writes[i].Offset); err != nil {
+				return undone, err
+			}
+		}
+		if err := file.Sync(); err != nil {
+			return undone, err
+		}
+
+		entries = entries[:len(entries)-1]
+		undone = append(undone, entry)
+		if !DryRunActive() {
+			undoMutex.Lock()
+			undoLogs[key] = entries
+			undoMutex.Unlock()
+		}
+	}
+	return undone, nil
+}
+
+// Funcion para verificar que el disco tenga lo que dejaron las escrituras de un comando
+// Si el comando escribió varias veces en la misma posición solo cuenta la última escritura
+func verifyUndo(file BlockDevice, entry UndoEntry) error {
+	expected := make(map[int64]byte)
+	for _, write := range entry.Writes {
+		for i, value := range write.New {
+			expected[write.Offset+int64(i)] = value
+		}
+	}
+	for _, write := range entry.Writes {
+		current := make([]byte, len(write.New))
+		if _, err := file.ReadAt(current, write.Offset); err != nil && err != io.EOF {
+			return err
+		}
+		for i, value := range current {
+			if expected[write.Offset+int64(i)] != value {
+				return fmt.Errorf("%w: %s escribió en la posición %d", ErrUndoConflict, entry.Command, write.Offset+int64(i))
+			}
+		}
+	}
+	return nil
+}
+
+// Funcion para abrir un disco sin registrar sus escrituras
+func openRaw(name string) (BlockDevice, error) {
+	if MemoryMode() {
+		return openMemoryDevice(name)
+	}
+	file, err := os.OpenFile(name, os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileDevice{file}, nil
+}
@@ -0,0 +1,92 @@
+package Utilities
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// Funcion para crear un disco en memoria de size bytes, abierto con registro de escrituras
+func newUndoDisk(t *testing.T, name string, size int64) BlockDevice {
+	t.Helper()
+	SetMemoryMode(true)
+	t.Cleanup(func() { SetMemoryMode(false) })
+	if err := CreateFile(name); err != nil {
+		t.Fatal(err)
+	}
+	file, err := OpenFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Truncate(size); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+// Funcion para ejecutar escrituras como si fueran un comando
+func undoCommand(t *testing.T, file BlockDevice, command string, writes map[int64][]byte) {
+	t.Helper()
+	BeginUndo(command)
+	defer EndUndo()
+	for position, data := range writes {
+		if err := WriteObject(file, data, position); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestUndoBudget(t *testing.T) {
+	file := newUndoDisk(t, "/undo/budget.mia", 2*undoBudget)
+
+	undoCommand(t, file, "pequeño", map[int64][]byte{0: []byte("abc")})
+	// Un comando que por sí solo se pasa del límite descarta el registro completo del disco
+	undoCommand(t, file, "grande", map[int64][]byte{16: make([]byte, undoBudget/2+1)})
+	if entries := UndoEntries("/undo/budget.mia"); len(entries) != 0 {
+		t.Fatalf("se esperaba un registro vacío y tiene %d comando(s)", len(entries))
+	}
+
+	// Al pasarse del límite entre varios comandos se descartan los más antiguos
+	for _, command := range []string{"a", "b", "c"} {
+		undoCommand(t, file, command, map[int64][]byte{0: make([]byte, undoBudget/5)})
+	}
+	entries := UndoEntries("/undo/budget.mia")
+	if len(entries) != 2 || entries[0].Command != "b" || entries[1].Command != "c" {
+		t.Fatalf("se esperaban los comandos b y c y el registro tiene %v", entries)
+	}
+}
+
+func TestApplyUndoConflict(t *testing.T) {
+	name := "/undo/conflict.mia"
+	file := newUndoDisk(t, name, 4096)
+	defer file.Close()
+	undoCommand(t, file, "a", map[int64][]byte{0: []byte("aaa")})
+	undoCommand(t, file, "b", map[int64][]byte{100: []byte("bbb")})
+
+	// Un cambio que no pasó por el registro sobre lo que escribió a
+	raw, err := openRaw(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw.WriteAt([]byte("x"), 1)
+	raw.Close()
+
+	undone, err := ApplyUndo(name, 2)
+	if !errors.Is(err, ErrUndoConflict) {
+		t.Fatalf("se esperaba ErrUndoConflict y se obtuvo %v", err)
+	}
+	if len(undone) != 1 || undone[0].Command != "b" {
+		t.Fatalf("solo b se debía deshacer y se deshicieron %v", undone)
+	}
+	if entries := UndoEntries(name); len(entries) != 1 || entries[0].Command != "a" {
+		t.Fatalf("a debía quedar en el registro y el registro tiene %v", entries)
+	}
+
+	content := make([]byte, 103)
+	if _, err := file.ReadAt(content, 0); err != nil {
+		t.Fatal(err)
+	}
+	if string(content[:3]) != "axa" || !bytes.Equal(content[100:], make([]byte, 3)) {
+		t.Fatalf("contenido inesperado %q ... %q", content[:3], content[100:])
+	}
+}
@@ -1,3 +1,8 @@
 module proyecto1
 
 go 1.22.6
+
+require (
+	golang.org/x/image v0.18.0
+	golang.org/x/net v0.35.0
+)
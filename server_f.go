@@ -7,7 +7,8 @@ import (
 	"os"
 	"os/signal"
 	"proyecto1/Analyzer"
-	"proyecto1/DiskManagement"
+	"proyecto1/Utilities"
+	"strings"
 	"syscall"
 	"time"
 )
@@ -15,9 +16,10 @@ import (
 func enableCors(next http.Handler) http.Handler {
     return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
         w.Header().Set("Access-Control-Allow-Origin", "*")
-        w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-        w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-        if r.Method == "OPTIONS" {
+        w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE, PROPFIND, PROPPATCH, MKCOL, COPY, MOVE, LOCK, UNLOCK")
+        w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Depth, Destination, Overwrite")
+        // Los clientes de WebDAV usan OPTIONS para conocer las capacidades del servidor
+        if r.Method == "OPTIONS" && !strings.HasPrefix(r.URL.Path, "/webdav/") {
             w.WriteHeader(http.StatusOK)
             return
         }
@@ -27,9 +29,23 @@ func enableCors(next http.Handler) http.Handler {
 
 
 func main() {
+    // Con MIA_MEMORY=1 los discos se crean en memoria y no se escriben en el host
+    if os.Getenv("MIA_MEMORY") == "1" {
+        Utilities.SetMemoryMode(true)
+        log.Println("Discos en memoria activados")
+    }
+
+    // Con MIA_SCRIPTS execute solo lee scripts de esa carpeta
+    Analyzer.ScriptsDir = os.Getenv("MIA_SCRIPTS")
+
     mux := http.NewServeMux()
     mux.HandleFunc("/prueba", Analyzer.ImprimirHandler)
     mux.HandleFunc("/analyze", Analyzer.AnalyzeHandler)
+    mux.HandleFunc("POST /analyze/stream", Analyzer.StreamHandler)
+    mux.HandleFunc("GET /reports/{id}/{name}", Analyzer.ReportDataHandler)
+    mux.HandleFunc("GET /reports/{id}/{name}/image", Analyzer.ReportImageHandler)
+    mux.HandleFunc("/webdav/{id}/", Analyzer.WebDAVHandler)
+    mux.HandleFunc("/fs/{id}/{path...}", Analyzer.FilesHandler)
 
     server := &http.Server{
         Addr:    ":8080",
@@ -55,7 +71,9 @@ func main() {
     log.Println("Apagando servidor...")
 
     // Llamada a la función de limpieza antes de apagar el servidor
-    DiskManagement.Clean()
+    if err := Analyzer.Clean(); err != nil {
+        log.Println("Error al desmontar las particiones:", err)
+    }
 
     // Contexto con timeout para darle tiempo al servidor de cerrar correctamente
     ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)